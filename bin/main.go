@@ -149,9 +149,17 @@ func main() {
 	defer provider.Shutdown(context.Background())
 
 	// 2. 初始化各个模块的追踪器
-	userMod := newUserModule(provider.Tracer("user-module"))
-	orderMod := newOrderModule(provider.Tracer("order-module"))
-	inventoryMod := newInventoryModule(provider.Tracer("inventory-module"))
+	// 每个模块注册为独立的虚拟服务，共用同一个导出管道，在后端显示为不同的服务
+	for _, name := range []string{"user-module", "order-module", "inventory-module"} {
+		if _, err := provider.NewVirtualService(name); err != nil {
+			// 不能使用 log.Fatalf，os.Exit 会跳过 defer 中的 Shutdown，已缓存的 span 不会被导出
+			log.Printf("create virtual service %s failed: %v", name, err)
+			return
+		}
+	}
+	userMod := newUserModule(otelemetry.GetTracer("user-module"))
+	orderMod := newOrderModule(otelemetry.GetTracer("order-module"))
+	inventoryMod := newInventoryModule(otelemetry.GetTracer("inventory-module"))
 
 	// 3. 模拟完整的业务流程：创建用户 -> 检查库存 -> 创建订单
 	ctx := context.Background()
@@ -0,0 +1,194 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DefaultDebugHeader 默认的调试请求头
+	DefaultDebugHeader = "X-Taurus-Debug"
+	// DefaultDebugBaggageKey 默认的调试 baggage 键
+	DefaultDebugBaggageKey = "taurus.debug"
+)
+
+// SamplingDebugKey 强制采样的 span 上会带上该属性
+const SamplingDebugKey = attribute.Key("sampling.debug")
+
+// debugSamplingKey 上下文中标记强制采样的键
+const debugSamplingKey = contextKey("debug_sampling")
+
+// ForceSampling 返回标记了强制采样的上下文，之后以该上下文创建的 span 一定会被采样
+// 该函数不做密钥校验，只应在可信的代码路径中调用，外部输入请使用 OTelProvider.ContextWithDebug
+func ForceSampling(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugSamplingKey, true)
+}
+
+// debugSampler 调试采样器
+// 上下文中带有强制采样标记或合法的调试 baggage 时直接采样，否则交给被包装的采样器决定
+type debugSampler struct {
+	delegate   sdktrace.Sampler
+	header     string
+	baggageKey string
+	secret     string // 共享密钥，为空时请求头的值为 1 或 true 即可触发，且不接受 baggage
+}
+
+// newDebugSampler 创建调试采样器
+func newDebugSampler(delegate sdktrace.Sampler, header, baggageKey, secret string) *debugSampler {
+	if header == "" {
+		header = DefaultDebugHeader
+	}
+	if baggageKey == "" {
+		baggageKey = DefaultDebugBaggageKey
+	}
+	return &debugSampler{
+		delegate:   delegate,
+		header:     header,
+		baggageKey: baggageKey,
+		secret:     secret,
+	}
+}
+
+// ShouldSample 实现 sdktrace.Sampler 接口
+func (s *debugSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if !s.forced(p.ParentContext) {
+		return s.delegate.ShouldSample(p)
+	}
+	return sdktrace.SamplingResult{
+		Decision:   sdktrace.RecordAndSample,
+		Attributes: []attribute.KeyValue{SamplingDebugKey.Bool(true)},
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
+
+// Description 实现 sdktrace.Sampler 接口
+func (s *debugSampler) Description() string {
+	return fmt.Sprintf("DebugSampler{header:%s,baggage:%s,delegate:%s}", s.header, s.baggageKey, s.delegate.Description())
+}
+
+// forced 判断上下文是否要求强制采样
+// baggage 会被传播到所有下游，任何外部客户端都能设置，因此只有配置了共享密钥时才接受 baggage
+func (s *debugSampler) forced(ctx context.Context) bool {
+	if forced, ok := ctx.Value(debugSamplingKey).(bool); ok && forced {
+		return true
+	}
+	if s.secret == "" {
+		return false
+	}
+	if m := baggage.FromContext(ctx).Member(s.baggageKey); m.Key() != "" {
+		return s.valid(m.Value())
+	}
+	return false
+}
+
+// stripBaggage 校验并移除 baggage 中的调试标记，避免共享密钥随 baggage 明文传播到下游
+// 校验通过时替换为不含密钥的标记 <baggageKey>=1，否则直接移除；found 表示 baggage 中是否有调试标记
+func (s *debugSampler) stripBaggage(bag baggage.Baggage) (stripped baggage.Baggage, forced, found bool) {
+	m := bag.Member(s.baggageKey)
+	if m.Key() == "" {
+		return bag, false, false
+	}
+	forced = s.secret != "" && s.valid(m.Value())
+	bag = bag.DeleteMember(s.baggageKey)
+	if forced {
+		if marker, err := baggage.NewMemberRaw(s.baggageKey, "1"); err == nil {
+			bag, _ = bag.SetMember(marker)
+		}
+	}
+	return bag, forced, true
+}
+
+// valid 校验调试标记的值
+// 配置了共享密钥时必须与密钥一致，否则值为 1 或 true 即可
+func (s *debugSampler) valid(value string) bool {
+	if value == "" {
+		return false
+	}
+	if s.secret != "" {
+		return subtle.ConstantTimeCompare([]byte(value), []byte(s.secret)) == 1
+	}
+	return value == "1" || strings.EqualFold(value, "true")
+}
+
+// ContextWithDebug 校验调试标记的值，合法时返回标记了强制采样的上下文
+// value: 调试请求头或 gRPC metadata 中的值，未启用调试采样或校验失败时原样返回 ctx
+func (p *OTelProvider) ContextWithDebug(ctx context.Context, value string) context.Context {
+	if p.debugSampler == nil || !p.debugSampler.valid(value) {
+		return ctx
+	}
+	return ForceSampling(ctx)
+}
+
+// ContextWithDebugBaggage 校验上下文 baggage 中的调试标记，合法时返回标记了强制采样的上下文
+// 无论是否合法，baggage 中的共享密钥都会被移除，合法时替换为 <baggageKey>=1
+// 用于 gRPC、消息消费等非 HTTP 的入口，需在提取 baggage 之后、创建 span 之前调用
+func (p *OTelProvider) ContextWithDebugBaggage(ctx context.Context) context.Context {
+	if p.debugSampler == nil {
+		return ctx
+	}
+	bag, forced, found := p.debugSampler.stripBaggage(baggage.FromContext(ctx))
+	if !found {
+		return ctx
+	}
+	ctx = baggage.ContextWithBaggage(ctx, bag)
+	if forced {
+		ctx = ForceSampling(ctx)
+	}
+	return ctx
+}
+
+// DebugSamplingMiddleware HTTP 中间件，请求带有合法的调试请求头或调试 baggage 时强制采样
+// 需要放在创建 span 的追踪中间件之前；请求 baggage 头中的共享密钥会被移除，不会传播到下游
+// 未配置共享密钥时只接受调试请求头，且任何外部客户端都能通过该请求头触发强制采样
+func (p *OTelProvider) DebugSamplingMiddleware(next http.Handler) http.Handler {
+	if p.debugSampler == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if value := r.Header.Get(p.debugSampler.header); value != "" {
+			ctx = p.ContextWithDebug(ctx, value)
+		}
+		if values := r.Header.Values("baggage"); len(values) > 0 {
+			if bag, err := baggage.Parse(strings.Join(values, ",")); err == nil {
+				if bag, forced, found := p.debugSampler.stripBaggage(bag); found {
+					if bag.Len() > 0 {
+						r.Header.Set("baggage", bag.String())
+					} else {
+						r.Header.Del("baggage")
+					}
+					if forced {
+						ctx = ForceSampling(ctx)
+					}
+				}
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
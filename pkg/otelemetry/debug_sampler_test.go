@@ -0,0 +1,221 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testDebugSecret = "s3cret"
+
+// newTestDebugProvider 创建只启用了调试采样的 provider，被包装的采样器不采样任何 trace
+func newTestDebugProvider(secret string) *OTelProvider {
+	return &OTelProvider{debugSampler: newDebugSampler(sdktrace.NeverSample(), "", "", secret)}
+}
+
+// debugBaggageContext 返回带有调试 baggage 的上下文
+func debugBaggageContext(t *testing.T, value string) context.Context {
+	t.Helper()
+	bag, err := baggage.Parse("taurus.debug=" + value + ",tenant=acme")
+	if err != nil {
+		t.Fatalf("parse baggage: %v", err)
+	}
+	return baggage.ContextWithBaggage(context.Background(), bag)
+}
+
+func isForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(debugSamplingKey).(bool)
+	return forced
+}
+
+func TestDebugSamplerSecretComparison(t *testing.T) {
+	tests := []struct {
+		secret string
+		value  string
+		want   bool
+	}{
+		{testDebugSecret, testDebugSecret, true},
+		{testDebugSecret, "s3cre", false},
+		{testDebugSecret, "S3CRET", false},
+		{testDebugSecret, "1", false},
+		{testDebugSecret, "", false},
+		{"", "1", true},
+		{"", "TRUE", true},
+		{"", "yes", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		s := newDebugSampler(sdktrace.NeverSample(), "", "", tt.secret)
+		if got := s.valid(tt.value); got != tt.want {
+			t.Errorf("secret %q: valid(%q) = %v, want %v", tt.secret, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDebugSamplerShouldSample(t *testing.T) {
+	sample := func(s sdktrace.Sampler, ctx context.Context) sdktrace.SamplingResult {
+		return s.ShouldSample(sdktrace.SamplingParameters{ParentContext: ctx, TraceID: trace.TraceID{1}, Name: "root"})
+	}
+	withSecret := newDebugSampler(sdktrace.NeverSample(), "", "", testDebugSecret)
+	noSecret := newDebugSampler(sdktrace.NeverSample(), "", "", "")
+
+	r := sample(withSecret, ForceSampling(context.Background()))
+	if r.Decision != sdktrace.RecordAndSample || len(r.Attributes) != 1 || r.Attributes[0] != SamplingDebugKey.Bool(true) {
+		t.Fatalf("forced context = %+v, want sampled with %s", r, SamplingDebugKey)
+	}
+	if d := sample(withSecret, debugBaggageContext(t, testDebugSecret)).Decision; d != sdktrace.RecordAndSample {
+		t.Errorf("baggage with the secret = %v, want RecordAndSample", d)
+	}
+	if d := sample(withSecret, debugBaggageContext(t, "wrong")).Decision; d != sdktrace.Drop {
+		t.Errorf("baggage with a wrong secret = %v, want Drop", d)
+	}
+	// 未配置密钥时 baggage 可以被任何客户端设置，不能触发强制采样
+	if d := sample(noSecret, debugBaggageContext(t, "1")).Decision; d != sdktrace.Drop {
+		t.Errorf("baggage without a configured secret = %v, want Drop", d)
+	}
+	if d := sample(noSecret, context.Background()).Decision; d != sdktrace.Drop {
+		t.Errorf("plain context = %v, want the delegate decision Drop", d)
+	}
+}
+
+func TestContextWithDebugBaggage(t *testing.T) {
+	p := newTestDebugProvider(testDebugSecret)
+
+	ctx := p.ContextWithDebugBaggage(debugBaggageContext(t, testDebugSecret))
+	bag := baggage.FromContext(ctx)
+	if !isForced(ctx) {
+		t.Error("valid debug baggage did not force sampling")
+	}
+	if v := bag.Member("taurus.debug").Value(); v != "1" {
+		t.Errorf("taurus.debug = %q, the secret must be replaced by 1", v)
+	}
+	if v := bag.Member("tenant").Value(); v != "acme" {
+		t.Errorf("tenant = %q, other members must be kept", v)
+	}
+
+	ctx = p.ContextWithDebugBaggage(debugBaggageContext(t, "wrong"))
+	if isForced(ctx) {
+		t.Error("wrong secret forced sampling")
+	}
+	if m := baggage.FromContext(ctx).Member("taurus.debug"); m.Key() != "" {
+		t.Errorf("invalid debug member %q was kept", m.Value())
+	}
+
+	// 未配置密钥时同样移除调试标记，但不强制采样
+	ctx = newTestDebugProvider("").ContextWithDebugBaggage(debugBaggageContext(t, "1"))
+	if isForced(ctx) || baggage.FromContext(ctx).Member("taurus.debug").Key() != "" {
+		t.Error("debug baggage accepted without a configured secret")
+	}
+
+	// 未启用调试采样时原样返回
+	plain := debugBaggageContext(t, testDebugSecret)
+	if ctx := (&OTelProvider{}).ContextWithDebugBaggage(plain); ctx != plain {
+		t.Error("provider without debug sampling changed the context")
+	}
+}
+
+func TestDebugSamplingMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		secret      string
+		header      string
+		baggage     string
+		wantForced  bool
+		wantBaggage string // 下游看到的 baggage 请求头中的 taurus.debug，空表示不存在
+	}{
+		{"header with secret", testDebugSecret, testDebugSecret, "", true, ""},
+		{"header with wrong secret", testDebugSecret, "wrong", "", false, ""},
+		{"header without secret", "", "1", "", true, ""},
+		{"baggage with secret", testDebugSecret, "", "taurus.debug=" + testDebugSecret + ",tenant=acme", true, "1"},
+		{"baggage with wrong secret", testDebugSecret, "", "taurus.debug=wrong,tenant=acme", false, ""},
+		{"baggage without secret", "", "", "taurus.debug=1,tenant=acme", false, ""},
+		{"baggage without debug member", testDebugSecret, "", "tenant=acme", false, ""},
+	}
+	for _, tt := range tests {
+		var (
+			forced bool
+			header string
+		)
+		handler := newTestDebugProvider(tt.secret).DebugSamplingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			forced = isForced(r.Context())
+			header = r.Header.Get("baggage")
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			req.Header.Set(DefaultDebugHeader, tt.header)
+		}
+		if tt.baggage != "" {
+			req.Header.Set("baggage", tt.baggage)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if forced != tt.wantForced {
+			t.Errorf("%s: forced = %v, want %v", tt.name, forced, tt.wantForced)
+		}
+		if tt.baggage == "" {
+			continue
+		}
+		bag, err := baggage.Parse(header)
+		if err != nil {
+			t.Fatalf("%s: downstream baggage %q: %v", tt.name, header, err)
+		}
+		if v := bag.Member("taurus.debug").Value(); v != tt.wantBaggage {
+			t.Errorf("%s: downstream taurus.debug = %q, want %q", tt.name, v, tt.wantBaggage)
+		}
+		if v := bag.Member("tenant").Value(); v != "acme" {
+			t.Errorf("%s: downstream tenant = %q, want acme", tt.name, v)
+		}
+	}
+}
+
+func TestTailSamplingKeepsDebugTraces(t *testing.T) {
+	p, next := newTestTailProcessor(t, WithTailPolicies(ErrorPolicy(), ProbabilisticPolicy(0)))
+	id := tailTraceID(1, 0)
+	start := time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)
+	p.OnEnd(tracetest.SpanStub{
+		Name: "debug",
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    id,
+			SpanID:     trace.SpanID{1},
+			TraceFlags: trace.FlagsSampled,
+		}),
+		StartTime:  start,
+		EndTime:    start.Add(time.Millisecond),
+		Status:     sdktrace.Status{Code: codes.Unset},
+		Attributes: []attribute.KeyValue{SamplingDebugKey.Bool(true)},
+	}.Snapshot())
+	p.OnEnd(tailSpan(id, 2, time.Millisecond, codes.Unset))
+	_ = p.ForceFlush(context.Background())
+
+	if got := next.spans()[id]; got != 2 {
+		t.Fatalf("debug trace forwarded %d spans, want 2", got)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// ErrorClass 错误在 span 上的记录方式
+type ErrorClass int
+
+const (
+	// ErrorClassError 记录错误事件，并将 span 状态标记为错误
+	ErrorClassError ErrorClass = iota
+	// ErrorClassEvent 只记录错误事件，不影响 span 状态
+	ErrorClassEvent
+	// ErrorClassIgnore 不记录
+	ErrorClassIgnore
+)
+
+// ErrorTypeKey span 上记录错误类型的属性，取值为 canceled、timeout 或错误的 Go 类型
+const ErrorTypeKey = attribute.Key("error.type")
+
+// ErrorClassifier 判断错误的记录方式，GORM、database/sql 和 Redis 的钩子共用
+type ErrorClassifier func(err error) ErrorClass
+
+// Classify 判断错误的记录方式，分类器为 nil 时使用 DefaultErrorClassifier
+func (c ErrorClassifier) Classify(err error) ErrorClass {
+	if c == nil {
+		return DefaultErrorClassifier(err)
+	}
+	return c(err)
+}
+
+// DefaultErrorClassifier 默认的错误分类
+// gorm.ErrRecordNotFound、redis.Nil 是正常的查询结果，忽略；
+// 调用方取消的请求不是服务端错误，只记录为事件；其余错误都标记为错误
+func DefaultErrorClassifier(err error) ErrorClass {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, redis.Nil):
+		return ErrorClassIgnore
+	case errors.Is(err, context.Canceled):
+		return ErrorClassEvent
+	default:
+		return ErrorClassError
+	}
+}
+
+// recordError 按分类在 span 上记录错误
+// classify 为 nil 时使用 DefaultErrorClassifier
+func recordError(span trace.Span, err error, classify ErrorClassifier) {
+	if err == nil {
+		return
+	}
+	class := classify.Classify(err)
+	if class == ErrorClassIgnore {
+		return
+	}
+	span.SetAttributes(ErrorTypeKey.String(errorType(err)))
+	span.RecordError(err)
+	if class == ErrorClassError {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// errorType 返回错误类型，取消和超时单独区分，便于和服务端错误分开统计
+func errorType(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return fmt.Sprintf("%T", err)
+	}
+}
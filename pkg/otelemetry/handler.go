@@ -19,26 +19,249 @@
 package otelemetry
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
 
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // 注册配置的调用链
 
-var tracerRegistry = make(map[string]trace.Tracer)
+// ScopeInfo 已注册的追踪器作用域信息
+type ScopeInfo struct {
+	Name      string // 作用域名称，即追踪器名称
+	Version   string // 插桩库版本
+	SchemaURL string // 语义约定的 schema 地址
+	Enabled   bool   // 是否启用
+}
 
-func RegisterTracer(name string, tracer trace.Tracer) {
-	if _, exists := tracerRegistry[name]; exists {
+// tracerScope 单个追踪器作用域
+type tracerScope struct {
+	name    string
+	opts    []trace.TracerOption
+	config  trace.TracerConfig
+	enabled atomic.Bool
+
+	tracer trace.Tracer                // 通过 RegisterTracer 注册的追踪器，为 nil 时按提供者创建
+	bound  atomic.Pointer[boundTracer] // 按当前提供者创建的追踪器
+}
+
+// boundTracer 追踪器及创建它的提供者
+type boundTracer struct {
+	provider trace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// TracerRegistry 并发安全的追踪器注册表
+// 追踪器在首次使用时才创建，并支持在运行时按作用域启用或禁用
+type TracerRegistry struct {
+	provider trace.TracerProvider // 为 nil 时使用全局的 otel.GetTracerProvider()
+
+	mu     sync.RWMutex
+	scopes map[string]*tracerScope
+}
+
+// NewTracerRegistry 创建追踪器注册表
+// provider: 用于创建追踪器的提供者，为 nil 时使用全局提供者
+func NewTracerRegistry(provider trace.TracerProvider) *TracerRegistry {
+	return &TracerRegistry{
+		provider: provider,
+		scopes:   make(map[string]*tracerScope),
+	}
+}
+
+// Register 注册追踪器作用域，追踪器在首次使用时才创建
+// name: 作用域名称
+// opts: 作用域元数据，如 trace.WithInstrumentationVersion、trace.WithSchemaURL
+func (r *TracerRegistry) Register(name string, opts ...trace.TracerOption) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enabled := true
+	if s, exists := r.scopes[name]; exists {
 		log.Printf("Tracer %s already registered", name)
+		enabled = s.enabled.Load()
 	}
-	tracerRegistry[name] = tracer
+	r.scopes[name] = r.newScope(name, nil, opts, enabled)
 }
 
-func GetTracer(name string) trace.Tracer {
-	if tracer, exists := tracerRegistry[name]; exists {
-		return tracer
+// RegisterTracer 注册一个已创建好的追踪器
+func (r *TracerRegistry) RegisterTracer(name string, tracer trace.Tracer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enabled := true
+	if s, exists := r.scopes[name]; exists {
+		log.Printf("Tracer %s already registered", name)
+		enabled = s.enabled.Load()
+	}
+	r.scopes[name] = r.newScope(name, tracer, nil, enabled)
+}
+
+// Tracer 返回指定作用域的追踪器，作用域未注册时按 opts 自动注册
+// 返回的追踪器会实时检查作用域的启用状态，禁用期间创建的 span 不会被记录
+func (r *TracerRegistry) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	r.mu.RLock()
+	_, exists := r.scopes[name]
+	r.mu.RUnlock()
+	if exists {
+		return &scopedTracer{registry: r, name: name}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists = r.scopes[name]; !exists {
+		r.scopes[name] = r.newScope(name, nil, opts, true)
+	}
+	return &scopedTracer{registry: r, name: name}
+}
+
+// MustTracer 返回已注册作用域的追踪器，作用域未注册时 panic
+func (r *TracerRegistry) MustTracer(name string) trace.Tracer {
+	r.mu.RLock()
+	_, exists := r.scopes[name]
+	r.mu.RUnlock()
+	if !exists {
+		panic(fmt.Sprintf("tracer %s not registered", name))
+	}
+	return &scopedTracer{registry: r, name: name}
+}
+
+// Scopes 返回所有已注册的作用域，按名称排序
+func (r *TracerRegistry) Scopes() []ScopeInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	scopes := make([]ScopeInfo, 0, len(r.scopes))
+	for _, s := range r.scopes {
+		scopes = append(scopes, ScopeInfo{
+			Name:      s.name,
+			Version:   s.config.InstrumentationVersion(),
+			SchemaURL: s.config.SchemaURL(),
+			Enabled:   s.enabled.Load(),
+		})
+	}
+	sort.Slice(scopes, func(i, j int) bool {
+		return scopes[i].Name < scopes[j].Name
+	})
+	return scopes
+}
+
+// SetEnabled 在运行时启用或禁用指定作用域，作用域未注册时会先注册
+func (r *TracerRegistry) SetEnabled(name string, enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, exists := r.scopes[name]
+	if !exists {
+		s = r.newScope(name, nil, nil, enabled)
+		r.scopes[name] = s
 	}
+	s.enabled.Store(enabled)
+}
+
+// newScope 创建作用域，调用方需持有锁
+func (r *TracerRegistry) newScope(name string, tracer trace.Tracer, opts []trace.TracerOption, enabled bool) *tracerScope {
+	s := &tracerScope{
+		name:   name,
+		opts:   opts,
+		config: trace.NewTracerConfig(opts...),
+		tracer: tracer,
+	}
+	s.enabled.Store(enabled)
+	return s
+}
+
+// tracerProvider 返回用于创建指定作用域追踪器的提供者
+// 优先使用注册表绑定的提供者，其次是以同名注册的提供者（虚拟服务），最后是全局提供者
+func (r *TracerRegistry) tracerProvider(name string) trace.TracerProvider {
+	if r.provider != nil {
+		return r.provider
+	}
+	if p := GetProvider(name); p != nil {
+		return p.tracerProvider
+	}
+	return otel.GetTracerProvider()
+}
+
+// scope 返回指定名称的作用域
+func (r *TracerRegistry) scope(name string) *tracerScope {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.scopes[name]
+}
+
+// tracer 返回作用域当前使用的追踪器
+// 未注册追踪器时每次都重新解析提供者，提供者变化（如之后才注册同名虚拟服务）时重新创建追踪器
+func (r *TracerRegistry) tracer(s *tracerScope) trace.Tracer {
+	if s.tracer != nil {
+		return s.tracer
+	}
+	provider := r.tracerProvider(s.name)
+	if b := s.bound.Load(); b != nil && b.provider == provider {
+		return b.tracer
+	}
+	b := &boundTracer{provider: provider, tracer: provider.Tracer(s.name, s.opts...)}
+	s.bound.Store(b)
+	return b.tracer
+}
+
+// scopedTracer 带启用开关的追踪器
+// 每次创建 span 时都会查找作用域，重新注册或切换开关后立即生效
+type scopedTracer struct {
+	embedded.Tracer
+
+	registry *TracerRegistry
+	name     string
+}
+
+// Start 实现 trace.Tracer 接口
+// 作用域被禁用时返回不记录的 span，父 span 的上下文会继续向下传递
+func (t *scopedTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	s := t.registry.scope(t.name)
+	if !s.enabled.Load() {
+		return noop.Tracer{}.Start(ctx, spanName, opts...)
+	}
+	return t.registry.tracer(s).Start(ctx, spanName, opts...)
+}
+
+// tracerRegistry 包级别默认的追踪器注册表
+var tracerRegistry = NewTracerRegistry(nil)
+
+// RegisterTracer 在默认注册表中注册一个已创建好的追踪器
+func RegisterTracer(name string, tracer trace.Tracer) {
+	tracerRegistry.RegisterTracer(name, tracer)
+}
+
+// RegisterTracerScope 在默认注册表中注册追踪器作用域，追踪器在首次使用时才创建
+// opts: 作用域元数据，如 trace.WithInstrumentationVersion、trace.WithSchemaURL
+func RegisterTracerScope(name string, opts ...trace.TracerOption) {
+	tracerRegistry.Register(name, opts...)
+}
+
+// GetTracer 从默认注册表获取追踪器，作用域未注册时自动注册
+func GetTracer(name string) trace.Tracer {
+	return tracerRegistry.Tracer(name)
+}
+
+// MustGetTracer 从默认注册表获取已注册的追踪器，作用域未注册时 panic
+func MustGetTracer(name string) trace.Tracer {
+	return tracerRegistry.MustTracer(name)
+}
+
+// TracerScopes 返回默认注册表中所有已注册的作用域
+func TracerScopes() []ScopeInfo {
+	return tracerRegistry.Scopes()
+}
 
-	return Provider.Tracer("default")
+// SetTracerEnabled 在运行时启用或禁用默认注册表中的指定作用域
+func SetTracerEnabled(name string, enabled bool) {
+	tracerRegistry.SetEnabled(name, enabled)
 }
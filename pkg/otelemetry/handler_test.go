@@ -0,0 +1,193 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestRegistry 创建绑定到 span recorder 的注册表
+func newTestRegistry() (*TracerRegistry, *tracetest.SpanRecorder) {
+	sr := tracetest.NewSpanRecorder()
+	return NewTracerRegistry(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))), sr
+}
+
+func TestTracerRegistryConcurrentAccess(t *testing.T) {
+	r, sr := newTestRegistry()
+	const (
+		workers    = 16
+		iterations = 200
+	)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				name := fmt.Sprintf("scope-%d", i%8)
+				switch (w + i) % 6 {
+				case 0:
+					r.Register(name, trace.WithInstrumentationVersion("1.0.0"))
+				case 1:
+					r.SetEnabled(name, i%2 == 0)
+				case 2:
+					_ = r.Scopes()
+				case 3:
+					r.RegisterTracer(name+"-custom", r.provider.Tracer(name))
+				}
+				_, span := r.Tracer(name).Start(context.Background(), "op")
+				span.End()
+				_, span = r.MustTracer(name).Start(context.Background(), "op")
+				span.End()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if n := len(r.Scopes()); n != 16 {
+		t.Fatalf("got %d scopes, want 16", n)
+	}
+	if len(sr.Ended()) == 0 {
+		t.Fatal("no span recorded")
+	}
+}
+
+func TestTracerRegistrySetEnabled(t *testing.T) {
+	r, sr := newTestRegistry()
+	tracer := r.Tracer("orders")
+
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), parent)
+
+	// 禁用后已获取的追踪器立即停止记录，父 span 的上下文继续向下传递
+	r.SetEnabled("orders", false)
+	child, span := tracer.Start(ctx, "disabled")
+	if span.IsRecording() {
+		t.Error("disabled scope created a recording span")
+	}
+	if got := trace.SpanContextFromContext(child); !got.Equal(parent) {
+		t.Errorf("disabled scope replaced the parent context: %v", got)
+	}
+	span.End()
+
+	r.SetEnabled("orders", true)
+	_, span = tracer.Start(ctx, "enabled")
+	span.End()
+	if spans := sr.Ended(); len(spans) != 1 || spans[0].Name() != "enabled" {
+		t.Fatalf("recorded %d spans, want only the span started while enabled", len(spans))
+	}
+
+	// 对未注册的作用域设置开关时先注册，重新注册保留开关状态
+	r.SetEnabled("payments", false)
+	r.Register("payments", trace.WithInstrumentationVersion("2.0.0"))
+	if _, span := r.Tracer("payments").Start(context.Background(), "op"); span.IsRecording() {
+		t.Error("re-registering enabled a disabled scope")
+	}
+}
+
+func TestTracerRegistryMustTracer(t *testing.T) {
+	r, _ := newTestRegistry()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("MustTracer did not panic for an unregistered scope")
+			}
+		}()
+		r.MustTracer("missing")
+	}()
+
+	r.Register("orders")
+	if _, span := r.MustTracer("orders").Start(context.Background(), "op"); !span.IsRecording() {
+		t.Error("MustTracer returned a tracer that does not record")
+	}
+}
+
+func TestTracerRegistryScopes(t *testing.T) {
+	r, sr := newTestRegistry()
+	r.Register("orders", trace.WithInstrumentationVersion("1.2.0"), trace.WithSchemaURL("https://opentelemetry.io/schemas/1.26.0"))
+	r.Tracer("billing")
+	r.SetEnabled("audit", false)
+
+	want := []ScopeInfo{
+		{Name: "audit", Enabled: false},
+		{Name: "billing", Enabled: true},
+		{Name: "orders", Version: "1.2.0", SchemaURL: "https://opentelemetry.io/schemas/1.26.0", Enabled: true},
+	}
+	got := r.Scopes()
+	if len(got) != len(want) {
+		t.Fatalf("scopes = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("scope %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// 作用域元数据会带到 span 的 instrumentation scope 上
+	_, span := r.Tracer("orders").Start(context.Background(), "op")
+	span.End()
+	scope := sr.Ended()[0].InstrumentationScope()
+	if scope.Name != "orders" || scope.Version != "1.2.0" {
+		t.Errorf("instrumentation scope = %+v", scope)
+	}
+}
+
+func TestTracerRegistryRegisterTracer(t *testing.T) {
+	r, _ := newTestRegistry()
+	custom := tracetest.NewSpanRecorder()
+	r.RegisterTracer("custom", sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(custom)).Tracer("custom"))
+
+	_, span := r.Tracer("custom").Start(context.Background(), "op")
+	span.End()
+	if len(custom.Ended()) != 1 {
+		t.Fatal("span was not created by the registered tracer")
+	}
+}
+
+func TestGetTracerUsesGlobalProvider(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(prev)
+
+	// 默认注册表中未注册的作用域按名称从全局提供者创建追踪器
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	_, span := GetTracer("handler-test-scope").Start(context.Background(), "op")
+	span.End()
+
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans on the global provider, want 1", len(spans))
+	}
+	if name := spans[0].InstrumentationScope().Name; name != "handler-test-scope" {
+		t.Errorf("instrumentation scope = %q, want the tracer name", name)
+	}
+}
@@ -54,7 +54,24 @@ type options struct {
 	timeout  time.Duration  // 导出超时时间
 
 	// 采样配置
-	samplingRatio float64 // 采样率，范围 0.0-1.0
+	samplingRatio      float64 // 采样率，范围 0.0-1.0
+	rateLimitPerSecond float64 // 每秒最多采样的根 trace 数，<=0 表示不限流
+	rateLimitBurst     int     // 限流令牌桶容量，允许的瞬时突发数
+	minSamplingRatio   float64 // 限流时的保底采样率，令牌耗尽后仍按该比例采样
+
+	// 远程采样配置
+	remoteSamplingURL      string        // 远程采样策略地址，为空表示不启用
+	remoteSamplingInterval time.Duration // 拉取远程采样策略的间隔
+
+	// 调试采样配置
+	debugSampling   bool   // 是否启用调试强制采样
+	debugHeader     string // 触发强制采样的请求头
+	debugBaggageKey string // 触发强制采样的 baggage 键
+	debugSecret     string // 共享密钥，为空时值为 1 或 true 即可触发
+
+	// 尾部采样配置
+	tailSampling     bool                 // 是否启用尾部采样
+	tailSamplingOpts []TailSamplingOption // 尾部采样选项
 
 	// 批处理配置
 	batchTimeout       time.Duration // 批处理超时时间
@@ -137,6 +154,42 @@ func WithSamplingRatio(ratio float64) Option {
 	}
 }
 
+// WithRemoteSampling 启用远程采样配置
+// url: Jaeger 远程采样接口地址，如 http://localhost:5778/sampling，请求时会带上 service 参数
+// interval: 拉取间隔，<=0 时默认 1 分钟
+// 拉取失败时沿用上一次成功的策略，从未成功时使用 samplingRatio（或限流采样）
+func WithRemoteSampling(url string, interval time.Duration) Option {
+	return func(o *options) {
+		o.remoteSamplingURL = url
+		o.remoteSamplingInterval = interval
+	}
+}
+
+// WithDebugSampling 启用调试强制采样，请求带有调试标记时无视采样率直接采样
+// header: 触发强制采样的请求头，为空时默认 X-Taurus-Debug
+// baggageKey: 触发强制采样的 baggage 键，为空时默认 taurus.debug
+// secret: 共享密钥，配置后标记的值必须与密钥一致，防止外部请求随意触发采样
+// 未配置密钥时只接受请求头（值为 1 或 true），任何外部客户端都能通过该请求头触发强制采样，baggage 不会被接受
+// baggage 中的密钥由 DebugSamplingMiddleware 或 ContextWithDebugBaggage 移除，不会传播到下游
+func WithDebugSampling(header, baggageKey, secret string) Option {
+	return func(o *options) {
+		o.debugSampling = true
+		o.debugHeader = header
+		o.debugBaggageKey = baggageKey
+		o.debugSecret = secret
+	}
+}
+
+// WithTailSampling 启用进程内尾部采样
+// opts: 尾部采样选项，如决策窗口、内存上限、采样策略
+// 启用后头部采样应保持全采样，由尾部采样决定最终保留哪些 trace
+func WithTailSampling(opts ...TailSamplingOption) Option {
+	return func(o *options) {
+		o.tailSampling = true
+		o.tailSamplingOpts = append(o.tailSamplingOpts, opts...)
+	}
+}
+
 // WithBatchTimeout 设置批处理超时时间
 // timeout: 批处理的超时时间
 func WithBatchTimeout(timeout time.Duration) Option {
@@ -176,3 +229,21 @@ func WithExportProtocol(protocol ExportProtocol) Option {
 		o.protocol = protocol
 	}
 }
+
+// WithRateLimitSampler 设置基于令牌桶的限流采样
+// perSecond: 每个进程每秒最多采样的根 trace 数，<=0 表示关闭限流，改用 samplingRatio
+// burst: 令牌桶容量，允许的瞬时突发数，<=0 时取 perSecond 向上取整
+func WithRateLimitSampler(perSecond float64, burst int) Option {
+	return func(o *options) {
+		o.rateLimitPerSecond = perSecond
+		o.rateLimitBurst = burst
+	}
+}
+
+// WithMinSamplingRatio 设置限流采样的保底采样率
+// ratio: 令牌耗尽后仍按该比例采样，范围 0.0-1.0，0 表示不保底
+func WithMinSamplingRatio(ratio float64) Option {
+	return func(o *options) {
+		o.minSamplingRatio = ratio
+	}
+}
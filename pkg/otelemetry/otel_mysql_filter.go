@@ -0,0 +1,87 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// shouldTrace 判断语句是否需要创建 span
+// sql 为空表示 SQL 尚未生成，此时不检查 SQL 规则
+func (h *GormTracingHook) shouldTrace(ctx context.Context, operation, table, sql string) bool {
+	if !h.parentAllowed(ctx) {
+		return false
+	}
+	if !matchNames(operation, h.IncludeOperations, h.ExcludeOperations) {
+		return false
+	}
+	if !matchNames(table, h.IncludeTables, h.ExcludeTables) {
+		return false
+	}
+	if sql == "" {
+		return true
+	}
+	if len(h.IncludeSQL) > 0 && !matchAnyRegexp(sql, h.IncludeSQL) {
+		return false
+	}
+	return !matchAnyRegexp(sql, h.ExcludeSQL)
+}
+
+// parentAllowed 判断上下文是否满足 RequireParentSpan 的要求
+func (h *GormTracingHook) parentAllowed(ctx context.Context) bool {
+	return !h.RequireParentSpan || trace.SpanContextFromContext(ctx).IsValid()
+}
+
+// hasSQLFilter 是否配置了 SQL 规则
+func (h *GormTracingHook) hasSQLFilter() bool {
+	return len(h.IncludeSQL) > 0 || len(h.ExcludeSQL) > 0
+}
+
+// matchNames 按包含和排除列表判断名称，不区分大小写
+func matchNames(name string, include, exclude []string) bool {
+	if len(include) > 0 && !containsFold(include, name) {
+		return false
+	}
+	return !containsFold(exclude, name)
+}
+
+func containsFold(names []string, name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAnyRegexp(s string, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"gorm.io/gorm"
+)
+
+// registerMetrics 注册连接池指标和语句耗时直方图
+// 连接池指标在采集时从 sql.DB.Stats() 读取，按 db.system 和 db.name 打标签
+func (h *GormTracingHook) registerMetrics(db *gorm.DB) error {
+	var err error
+	h.duration, err = h.Meter.Float64Histogram("db.client.operation.duration",
+		metric.WithDescription("数据库语句的执行耗时"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10))
+	if err != nil {
+		return err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	open, err := h.Meter.Int64ObservableGauge("db.client.connections.open",
+		metric.WithDescription("当前打开的连接数"),
+		metric.WithUnit("{connection}"))
+	if err != nil {
+		return err
+	}
+	inUse, err := h.Meter.Int64ObservableGauge("db.client.connections.in_use",
+		metric.WithDescription("正在使用的连接数"),
+		metric.WithUnit("{connection}"))
+	if err != nil {
+		return err
+	}
+	idle, err := h.Meter.Int64ObservableGauge("db.client.connections.idle",
+		metric.WithDescription("空闲的连接数"),
+		metric.WithUnit("{connection}"))
+	if err != nil {
+		return err
+	}
+	waitCount, err := h.Meter.Int64ObservableCounter("db.client.connections.wait_count",
+		metric.WithDescription("等待连接的总次数"),
+		metric.WithUnit("{wait}"))
+	if err != nil {
+		return err
+	}
+	waitDuration, err := h.Meter.Float64ObservableCounter("db.client.connections.wait_duration",
+		metric.WithDescription("等待连接的总耗时"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return err
+	}
+	maxLifetimeClosed, err := h.Meter.Int64ObservableCounter("db.client.connections.max_lifetime_closed",
+		metric.WithDescription("因超过最大存活时间而关闭的连接总数"),
+		metric.WithUnit("{connection}"))
+	if err != nil {
+		return err
+	}
+
+	attrs := metric.WithAttributeSet(h.metricAttributes())
+	_, err = h.Meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		stats := sqlDB.Stats()
+		o.ObserveInt64(open, int64(stats.OpenConnections), attrs)
+		o.ObserveInt64(inUse, int64(stats.InUse), attrs)
+		o.ObserveInt64(idle, int64(stats.Idle), attrs)
+		o.ObserveInt64(waitCount, stats.WaitCount, attrs)
+		o.ObserveFloat64(waitDuration, stats.WaitDuration.Seconds(), attrs)
+		o.ObserveInt64(maxLifetimeClosed, stats.MaxLifetimeClosed, attrs)
+		return nil
+	}, open, inUse, idle, waitCount, waitDuration, maxLifetimeClosed)
+	return err
+}
+
+// metricAttributes 返回连接池指标的标签
+func (h *GormTracingHook) metricAttributes() attribute.Set {
+	return attribute.NewSet(
+		attribute.String("db.system", h.system),
+		attribute.String("db.name", h.dbName),
+	)
+}
+
+// recordDuration 按操作类型和表名记录语句耗时
+func (h *GormTracingHook) recordDuration(s *gormSpan, operation, table string) {
+	if h.duration == nil {
+		return
+	}
+	h.duration.Record(s.parent, time.Since(s.start).Seconds(), metric.WithAttributes(
+		attribute.String("db.system", h.system),
+		attribute.String("db.name", h.dbName),
+		attribute.String("db.operation", operation),
+		attribute.String("db.sql.table", table),
+	))
+}
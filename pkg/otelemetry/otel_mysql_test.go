@@ -0,0 +1,637 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testUser GORM 测试使用的模型
+type testUser struct {
+	ID   uint
+	Name string
+}
+
+// newTestGormDB 创建安装了 hook 的 sqlite 数据库，建表语句在安装 hook 之前执行，不产生 span
+// hook.Tracer 为空时使用返回的 recorder 记录 span，返回的 tracer 用于创建父 span
+func newTestGormDB(t *testing.T, hook *GormTracingHook) (*gorm.DB, *tracetest.SpanRecorder, trace.Tracer) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+		// 写操作默认包在事务中，会多出 db.transaction span，事务由 TestGormTransactionSpans 单独覆盖
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql db: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err := db.AutoMigrate(&testUser{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	sr := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("gorm-test")
+	if hook.Tracer == nil {
+		hook.Tracer = tracer
+	}
+	if err := db.Use(hook); err != nil {
+		t.Fatalf("use hook: %v", err)
+	}
+	return db, sr, tracer
+}
+
+// spanNames 返回已结束 span 的名称
+func spanNames(sr *tracetest.SpanRecorder) []string {
+	var names []string
+	for _, s := range sr.Ended() {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+// spanByName 返回第一个名称为 name 的已结束 span
+func spanByName(t *testing.T, sr *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, s := range sr.Ended() {
+		if s.Name() == name {
+			return s
+		}
+	}
+	t.Fatalf("no span %q in %v", name, spanNames(sr))
+	return nil
+}
+
+// attrValue 返回属性列表中 key 的值，不存在时返回无效值
+func attrValue(attrs []attribute.KeyValue, key attribute.Key) attribute.Value {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+// runGormOperations 依次执行模型写入、模型查询和原生 SQL
+func runGormOperations(t *testing.T, ctx context.Context, db *gorm.DB) {
+	t.Helper()
+	if err := db.WithContext(ctx).Create(&testUser{Name: "bob"}).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	var u testUser
+	if err := db.WithContext(ctx).First(&u).Error; err != nil {
+		t.Fatalf("first: %v", err)
+	}
+	if err := db.WithContext(ctx).Exec("DELETE FROM test_users WHERE name = ?", "alice").Error; err != nil {
+		t.Fatalf("exec: %v", err)
+	}
+}
+
+func TestGormSpanNaming(t *testing.T) {
+	db, sr, _ := newTestGormDB(t, &GormTracingHook{})
+	runGormOperations(t, context.Background(), db)
+
+	want := []string{"INSERT test_users", "SELECT test_users", "DELETE"}
+	if got := spanNames(sr); !reflect.DeepEqual(got, want) {
+		t.Fatalf("span names = %v, want %v", got, want)
+	}
+
+	insert := spanByName(t, sr, "INSERT test_users")
+	if insert.SpanKind() != trace.SpanKindClient {
+		t.Errorf("span kind = %v, want client", insert.SpanKind())
+	}
+	attrs := insert.Attributes()
+	if v := attrValue(attrs, "db.system").AsString(); v != "sqlite" {
+		t.Errorf("db.system = %q", v)
+	}
+	if v := attrValue(attrs, "db.operation").AsString(); v != "INSERT" {
+		t.Errorf("db.operation = %q", v)
+	}
+	if v := attrValue(attrs, "db.sql.table").AsString(); v != "test_users" {
+		t.Errorf("db.sql.table = %q", v)
+	}
+	if v := attrValue(attrs, "db.rows_affected").AsInt64(); v != 1 {
+		t.Errorf("db.rows_affected = %d", v)
+	}
+	// 默认只记录带占位符的 SQL
+	if v := attrValue(attrs, "db.statement").AsString(); !regexp.MustCompile(`^INSERT INTO .test_users.*\?`).MatchString(v) || regexp.MustCompile(`bob`).MatchString(v) {
+		t.Errorf("db.statement = %q, want the SQL with placeholders", v)
+	}
+
+	raw := spanByName(t, sr, "DELETE")
+	if v := attrValue(raw.Attributes(), "db.sql.table"); v.Type() != attribute.INVALID {
+		t.Errorf("raw SQL has db.sql.table %q", v.AsString())
+	}
+}
+
+func TestGormStatementModes(t *testing.T) {
+	tests := []struct {
+		mode SQLStatementMode
+		want *regexp.Regexp
+	}{
+		{SQLStatementFull, regexp.MustCompile(`name = "alice"`)},
+		{SQLStatementObfuscated, regexp.MustCompile(`name = \?$`)},
+	}
+	for _, tt := range tests {
+		db, sr, _ := newTestGormDB(t, &GormTracingHook{StatementMode: tt.mode})
+		db.Exec("DELETE FROM test_users WHERE name = ?", "alice")
+		if v := attrValue(spanByName(t, sr, "DELETE").Attributes(), "db.statement").AsString(); !tt.want.MatchString(v) {
+			t.Errorf("mode %v: db.statement = %q, want match %s", tt.mode, v, tt.want)
+		}
+	}
+}
+
+func TestGormNilTracerUsesGetTracer(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(prev)
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+
+	// 不经过 newTestGormDB，保持 Tracer 为 nil
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.Use(&GormTracingHook{}); err != nil {
+		t.Fatalf("use hook: %v", err)
+	}
+	if err := db.Exec("SELECT 1").Error; err != nil {
+		t.Fatalf("exec: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	_ = sqlDB.Close()
+
+	spans := sr.Ended()
+	if len(spans) != 1 || spans[0].InstrumentationScope().Name != "gorm" {
+		t.Fatalf("spans = %v, want one span from the gorm tracer", spanNames(sr))
+	}
+}
+
+func TestGormConnectionAttributes(t *testing.T) {
+	dsns := []struct {
+		system string
+		dsn    string
+		want   dsnInfo
+	}{
+		{"mysql", "user:pass@tcp(db.local:3306)/shop?parseTime=true", dsnInfo{"shop", "db.local", 3306}},
+		{"mysql", "user@unix(/tmp/mysql.sock)/shop", dsnInfo{"shop", "/tmp/mysql.sock", 0}},
+		{"mysql", "/shop", dsnInfo{dbName: "shop"}},
+		{"postgresql", "postgres://u:p@pg.local:5432/shop?sslmode=disable", dsnInfo{"shop", "pg.local", 5432}},
+		{"postgresql", "host=pg.local port=5433 dbname='shop' user=u", dsnInfo{"shop", "pg.local", 5433}},
+		{"mssql", "sqlserver://u:p@ms.local:1433?database=shop", dsnInfo{"shop", "ms.local", 1433}},
+		{"sqlite", "file:/data/app.db?cache=shared", dsnInfo{dbName: "/data/app.db"}},
+		{"clickhouse", "tcp://ch.local:9000/shop", dsnInfo{"shop", "ch.local", 9000}},
+		{"oracle", "shop", dsnInfo{}},
+		{"mysql", "", dsnInfo{}},
+	}
+	for _, tt := range dsns {
+		if got := parseDSN(tt.system, tt.dsn); got != tt.want {
+			t.Errorf("parseDSN(%s, %q) = %+v, want %+v", tt.system, tt.dsn, got, tt.want)
+		}
+	}
+
+	for name, want := range map[string]string{"postgres": "postgresql", "pgx": "postgresql", "sqlserver": "mssql", "sqlite3": "sqlite", "mysql": "mysql"} {
+		if got := dbSystem(name); got != want {
+			t.Errorf("dbSystem(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	// 官方驱动把 DSN 放在内嵌的 *Config 中
+	type config struct{ DSN string }
+	type dialector struct{ *config }
+	if got := findDSN(reflect.ValueOf(&dialector{&config{DSN: "root@/shop"}}), 2); got != "root@/shop" {
+		t.Errorf("findDSN through an embedded config = %q", got)
+	}
+	if got := findDSN(reflect.ValueOf(&dialector{}), 2); got != "" {
+		t.Errorf("findDSN with a nil config = %q", got)
+	}
+
+	// 手动设置的字段优先于推断的值
+	h := &GormTracingHook{DBName: "orders", ServerAddress: "db.internal", ServerPort: 3307}
+	attrs := h.connectionAttributes(sqlite.Open("file:/data/app.db"))
+	want := []attribute.KeyValue{
+		attribute.String("db.system", "sqlite"),
+		attribute.String("db.name", "orders"),
+		attribute.String("server.address", "db.internal"),
+		attribute.Int("server.port", 3307),
+	}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("connection attributes = %v, want %v", attrs, want)
+	}
+}
+
+func TestGormDeferredSpans(t *testing.T) {
+	db, sr, _ := newTestGormDB(t, &GormTracingHook{
+		IncludeSQL: []*regexp.Regexp{regexp.MustCompile(`^INSERT`)},
+	})
+	before := time.Now()
+	runGormOperations(t, context.Background(), db)
+
+	// 模型操作的 SQL 在执行后才能判断，命中的语句补建 span，开始时间为执行前的时间
+	if got := spanNames(sr); !reflect.DeepEqual(got, []string{"INSERT test_users"}) {
+		t.Fatalf("span names = %v, want only the insert", got)
+	}
+	s := sr.Ended()[0]
+	if s.StartTime().Before(before) || !s.StartTime().Before(s.EndTime()) {
+		t.Errorf("deferred span start = %v, end = %v, statement started after %v", s.StartTime(), s.EndTime(), before)
+	}
+}
+
+func TestGormFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		hook   GormTracingHook
+		parent bool
+		want   []string
+	}{
+		{"no filter", GormTracingHook{}, false, []string{"INSERT test_users", "SELECT test_users", "DELETE"}},
+		{"exclude table", GormTracingHook{ExcludeTables: []string{"TEST_USERS"}}, false, []string{"DELETE"}},
+		{"include table", GormTracingHook{IncludeTables: []string{"test_users"}}, false, []string{"INSERT test_users", "SELECT test_users"}},
+		{"include operation", GormTracingHook{IncludeOperations: []string{"select"}}, false, []string{"SELECT test_users"}},
+		{"exclude operation", GormTracingHook{ExcludeOperations: []string{"INSERT", "DELETE"}}, false, []string{"SELECT test_users"}},
+		{"exclude sql", GormTracingHook{ExcludeSQL: []*regexp.Regexp{regexp.MustCompile(`^DELETE`)}}, false, []string{"INSERT test_users", "SELECT test_users"}},
+		{"include and exclude", GormTracingHook{IncludeTables: []string{"test_users"}, ExcludeTables: []string{"test_users"}}, false, nil},
+		{"require parent without parent", GormTracingHook{RequireParentSpan: true}, false, nil},
+		{"require parent with parent", GormTracingHook{RequireParentSpan: true}, true, []string{"INSERT test_users", "SELECT test_users", "DELETE", "parent"}},
+	}
+	for _, tt := range tests {
+		hook := tt.hook
+		db, sr, tracer := newTestGormDB(t, &hook)
+		ctx := context.Background()
+		var parent trace.Span
+		if tt.parent {
+			ctx, parent = tracer.Start(ctx, "parent")
+		}
+		runGormOperations(t, ctx, db)
+		if parent != nil {
+			parent.End()
+		}
+		if got := spanNames(sr); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: span names = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGormTransactionSpans(t *testing.T) {
+	db, sr, tracer := newTestGormDB(t, &GormTracingHook{})
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&testUser{Name: "bob"}).Error; err != nil {
+			return err
+		}
+		// 嵌套事务使用保存点，返回错误时回滚到保存点
+		_ = tx.Transaction(func(tx *gorm.DB) error {
+			tx.Create(&testUser{Name: "alice"})
+			return errors.New("rollback nested")
+		})
+		return nil
+	})
+	parent.End()
+	if err != nil {
+		t.Fatalf("transaction: %v", err)
+	}
+
+	txSpan := spanByName(t, sr, "db.transaction")
+	if txSpan.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("transaction span is not a child of the caller's span")
+	}
+	attrs := txSpan.Attributes()
+	if v := attrValue(attrs, "db.transaction.outcome").AsString(); v != "commit" {
+		t.Errorf("outcome = %q, want commit", v)
+	}
+	// 两条 INSERT 加上 SAVEPOINT 和 ROLLBACK TO SAVEPOINT
+	if v := attrValue(attrs, "db.transaction.statements").AsInt64(); v != 4 {
+		t.Errorf("statements = %d, want 4", v)
+	}
+	if v := attrValue(attrs, "db.transaction.savepoints").AsInt64(); v != 1 {
+		t.Errorf("savepoints = %d, want 1", v)
+	}
+	var events []string
+	for _, e := range txSpan.Events() {
+		events = append(events, e.Name)
+	}
+	if !reflect.DeepEqual(events, []string{"db.savepoint", "db.savepoint.rollback"}) {
+		t.Errorf("transaction events = %v", events)
+	}
+
+	for _, s := range sr.Ended() {
+		if s.Name() == "INSERT test_users" && s.Parent().SpanID() != txSpan.SpanContext().SpanID() {
+			t.Error("statement span is not a child of the transaction span")
+		}
+	}
+}
+
+func TestGormTransactionAllFiltered(t *testing.T) {
+	db, sr, _ := newTestGormDB(t, &GormTracingHook{ExcludeTables: []string{"test_users"}})
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&testUser{Name: "bob"}).Error
+	})
+	if err != nil {
+		t.Fatalf("transaction: %v", err)
+	}
+	// 事务内的语句全部被过滤时不创建事务 span
+	if names := spanNames(sr); len(names) != 0 {
+		t.Errorf("span names = %v, want none", names)
+	}
+}
+
+func TestGormSlowQuery(t *testing.T) {
+	var slow []SlowQuery
+	db, sr, _ := newTestGormDB(t, &GormTracingHook{
+		SlowThreshold:       time.Nanosecond,
+		TableSlowThresholds: map[string]time.Duration{"test_users": time.Hour},
+		SlowQueryAsError:    true,
+		OnSlowQuery: func(ctx context.Context, info SlowQuery) {
+			if !trace.SpanContextFromContext(ctx).IsValid() {
+				t.Error("slow query callback without the statement span")
+			}
+			slow = append(slow, info)
+		},
+	})
+	runGormOperations(t, context.Background(), db)
+
+	// test_users 的阈值为 1 小时，只有没有表名的原生 SQL 使用全局阈值
+	if len(slow) != 1 {
+		t.Fatalf("slow queries = %+v, want only the raw delete", slow)
+	}
+	if info := slow[0]; info.Operation != "DELETE" || info.Table != "" || info.Threshold != time.Nanosecond || info.SQL != "DELETE FROM test_users WHERE name = ?" {
+		t.Errorf("slow query = %+v", info)
+	}
+
+	s := spanByName(t, sr, "DELETE")
+	if !attrValue(s.Attributes(), "db.slow_query").AsBool() {
+		t.Error("slow span misses db.slow_query")
+	}
+	if len(s.Events()) != 1 || s.Events()[0].Name != "db.slow_query" {
+		t.Errorf("slow span events = %+v", s.Events())
+	}
+	if s.Status().Code != codes.Error {
+		t.Errorf("slow span status = %v, want error with SlowQueryAsError", s.Status())
+	}
+	if attrValue(spanByName(t, sr, "SELECT test_users").Attributes(), "db.slow_query").AsBool() {
+		t.Error("table threshold did not override the global threshold")
+	}
+}
+
+func TestGormSlowQueryExplainSQL(t *testing.T) {
+	var sql string
+	db, sr, _ := newTestGormDB(t, &GormTracingHook{
+		SlowThreshold:       time.Nanosecond,
+		SlowQueryExplainSQL: true,
+		OnSlowQuery:         func(_ context.Context, info SlowQuery) { sql = info.SQL },
+	})
+	db.Exec("DELETE FROM test_users WHERE name = ?", "alice")
+
+	// 回调拿到代入参数值的 SQL，span 上仍是带占位符的 SQL
+	if sql != `DELETE FROM test_users WHERE name = "alice"` {
+		t.Errorf("slow query SQL = %q, want the explained SQL", sql)
+	}
+	s := spanByName(t, sr, "DELETE")
+	if v := attrValue(s.Attributes(), "db.statement").AsString(); v != "DELETE FROM test_users WHERE name = ?" {
+		t.Errorf("span db.statement = %q", v)
+	}
+	if v := attrValue(s.Events()[0].Attributes, "db.statement").AsString(); v != "DELETE FROM test_users WHERE name = ?" {
+		t.Errorf("slow query event db.statement = %q", v)
+	}
+}
+
+func TestGormErrors(t *testing.T) {
+	db, sr, _ := newTestGormDB(t, &GormTracingHook{})
+	var u testUser
+	if err := db.First(&u, 42).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("first = %v, want ErrRecordNotFound", err)
+	}
+	if err := db.Exec("SELECT * FROM missing_table").Error; err == nil {
+		t.Fatal("query on a missing table succeeded")
+	}
+
+	// 默认忽略 ErrRecordNotFound
+	if s := spanByName(t, sr, "SELECT test_users"); s.Status().Code != codes.Unset {
+		t.Errorf("record not found status = %v", s.Status())
+	}
+	if s := spanByName(t, sr, "SELECT"); s.Status().Code != codes.Error {
+		t.Errorf("failed query status = %v, want error", s.Status())
+	}
+}
+
+// testMeasurement 记录的一次测量
+type testMeasurement struct {
+	value float64
+	attrs attribute.Set
+}
+
+// testMeter 记录测量值的 metric.Meter，未覆盖的方法使用 noop 实现
+type testMeter struct {
+	noop.Meter
+
+	mu           sync.Mutex
+	callbacks    []metric.Callback
+	measurements map[string][]testMeasurement
+}
+
+func newTestMeter() *testMeter {
+	return &testMeter{measurements: make(map[string][]testMeasurement)}
+}
+
+func (m *testMeter) record(name string, value float64, attrs attribute.Set) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.measurements[name] = append(m.measurements[name], testMeasurement{value, attrs})
+}
+
+// get 返回 name 的全部测量值
+func (m *testMeter) get(name string) []testMeasurement {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]testMeasurement(nil), m.measurements[name]...)
+}
+
+// collect 执行注册的回调，相当于一次采集
+func (m *testMeter) collect(t *testing.T) {
+	t.Helper()
+	m.mu.Lock()
+	callbacks := append([]metric.Callback(nil), m.callbacks...)
+	m.mu.Unlock()
+	for _, cb := range callbacks {
+		if err := cb(context.Background(), testObserver{meter: m}); err != nil {
+			t.Fatalf("metric callback: %v", err)
+		}
+	}
+}
+
+func (m *testMeter) Float64Histogram(name string, _ ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return testFloat64Histogram{meter: m, name: name}, nil
+}
+
+func (m *testMeter) Int64Counter(name string, _ ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return testInt64Counter{meter: m, name: name}, nil
+}
+
+func (m *testMeter) Int64ObservableGauge(name string, _ ...metric.Int64ObservableGaugeOption) (metric.Int64ObservableGauge, error) {
+	return testInt64Gauge{name: name}, nil
+}
+
+func (m *testMeter) Int64ObservableCounter(name string, _ ...metric.Int64ObservableCounterOption) (metric.Int64ObservableCounter, error) {
+	return testInt64ObservableCounter{name: name}, nil
+}
+
+func (m *testMeter) Float64ObservableCounter(name string, _ ...metric.Float64ObservableCounterOption) (metric.Float64ObservableCounter, error) {
+	return testFloat64ObservableCounter{name: name}, nil
+}
+
+func (m *testMeter) RegisterCallback(cb metric.Callback, _ ...metric.Observable) (metric.Registration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.callbacks = append(m.callbacks, cb)
+	return noop.Registration{}, nil
+}
+
+type testFloat64Histogram struct {
+	noop.Float64Histogram
+	meter *testMeter
+	name  string
+}
+
+func (h testFloat64Histogram) Record(_ context.Context, v float64, opts ...metric.RecordOption) {
+	h.meter.record(h.name, v, metric.NewRecordConfig(opts).Attributes())
+}
+
+type testInt64Counter struct {
+	noop.Int64Counter
+	meter *testMeter
+	name  string
+}
+
+func (c testInt64Counter) Add(_ context.Context, v int64, opts ...metric.AddOption) {
+	c.meter.record(c.name, float64(v), metric.NewAddConfig(opts).Attributes())
+}
+
+type testInt64Gauge struct {
+	noop.Int64ObservableGauge
+	name string
+}
+
+type testInt64ObservableCounter struct {
+	noop.Int64ObservableCounter
+	name string
+}
+
+type testFloat64ObservableCounter struct {
+	noop.Float64ObservableCounter
+	name string
+}
+
+// testObserver 把回调中观测到的值记录到 testMeter
+type testObserver struct {
+	noop.Observer
+	meter *testMeter
+}
+
+func (o testObserver) ObserveInt64(obs metric.Int64Observable, v int64, opts ...metric.ObserveOption) {
+	var name string
+	switch i := obs.(type) {
+	case testInt64Gauge:
+		name = i.name
+	case testInt64ObservableCounter:
+		name = i.name
+	}
+	o.meter.record(name, float64(v), metric.NewObserveConfig(opts).Attributes())
+}
+
+func (o testObserver) ObserveFloat64(obs metric.Float64Observable, v float64, opts ...metric.ObserveOption) {
+	if i, ok := obs.(testFloat64ObservableCounter); ok {
+		o.meter.record(i.name, v, metric.NewObserveConfig(opts).Attributes())
+	}
+}
+
+func TestGormPoolMetrics(t *testing.T) {
+	meter := newTestMeter()
+	db, _, _ := newTestGormDB(t, &GormTracingHook{Meter: meter, DBName: "app", ExcludeTables: []string{"test_users"}})
+	runGormOperations(t, context.Background(), db)
+
+	// 被过滤的语句也记录耗时
+	durations := meter.get("db.client.operation.duration")
+	if len(durations) != 3 {
+		t.Fatalf("recorded %d durations, want 3", len(durations))
+	}
+	var ops []string
+	for _, m := range durations {
+		op, _ := m.attrs.Value("db.operation")
+		system, _ := m.attrs.Value("db.system")
+		name, _ := m.attrs.Value("db.name")
+		if system.AsString() != "sqlite" || name.AsString() != "app" {
+			t.Errorf("duration attributes = %v", m.attrs.ToSlice())
+		}
+		ops = append(ops, op.AsString())
+	}
+	sort.Strings(ops)
+	if !reflect.DeepEqual(ops, []string{"DELETE", "INSERT", "SELECT"}) {
+		t.Errorf("duration operations = %v", ops)
+	}
+
+	meter.collect(t)
+	sqlDB, _ := db.DB()
+	stats := sqlDB.Stats()
+	want := map[string]float64{
+		"db.client.connections.open":                float64(stats.OpenConnections),
+		"db.client.connections.in_use":              float64(stats.InUse),
+		"db.client.connections.idle":                float64(stats.Idle),
+		"db.client.connections.wait_count":          float64(stats.WaitCount),
+		"db.client.connections.wait_duration":       stats.WaitDuration.Seconds(),
+		"db.client.connections.max_lifetime_closed": float64(stats.MaxLifetimeClosed),
+	}
+	for name, v := range want {
+		got := meter.get(name)
+		if len(got) != 1 || got[0].value != v {
+			t.Errorf("%s = %+v, want %v", name, got, v)
+			continue
+		}
+		if dbName, _ := got[0].attrs.Value("db.name"); dbName.AsString() != "app" {
+			t.Errorf("%s attributes = %v", name, got[0].attrs.ToSlice())
+		}
+	}
+	if stats.OpenConnections == 0 {
+		t.Error("pool has no open connection after running statements")
+	}
+}
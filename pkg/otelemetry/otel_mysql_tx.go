@@ -0,0 +1,282 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/gorm"
+)
+
+// gormConnPool 包装 GORM 的连接池
+// GORM 的 Begin/Commit/Rollback 不经过回调，只能在连接池上拦截，为事务创建 db.transaction span
+type gormConnPool struct {
+	gorm.ConnPool
+	hook  *GormTracingHook
+	sqlDB *sql.DB // 原始的 *sql.DB，保证 db.DB() 在包装后仍然可用
+}
+
+// wrapConnPool 包装 db 的连接池，已经包装过时直接返回
+func (h *GormTracingHook) wrapConnPool(db *gorm.DB) {
+	if db.ConnPool == nil {
+		return
+	}
+	if _, ok := db.ConnPool.(*gormConnPool); ok {
+		return
+	}
+	sqlDB, _ := db.DB()
+	pool := &gormConnPool{ConnPool: db.ConnPool, hook: h, sqlDB: sqlDB}
+	db.ConnPool = pool
+	if db.Statement != nil {
+		db.Statement.ConnPool = pool
+	}
+}
+
+// GetDBConn 实现 gorm.GetDBConnector 接口
+func (p *gormConnPool) GetDBConn() (*sql.DB, error) {
+	if p.sqlDB != nil {
+		return p.sqlDB, nil
+	}
+	if connector, ok := p.ConnPool.(gorm.GetDBConnector); ok {
+		return connector.GetDBConn()
+	}
+	return nil, gorm.ErrInvalidDB
+}
+
+// Ping 检查连接是否可用
+func (p *gormConnPool) Ping() error {
+	if pinger, ok := p.ConnPool.(interface{ Ping() error }); ok {
+		return pinger.Ping()
+	}
+	return nil
+}
+
+// ExecContext 实现 gorm.ConnPool 接口，按配置为 SQL 追加 sqlcommenter 注释
+func (p *gormConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.ConnPool.ExecContext(ctx, p.comment(ctx, query), args...)
+}
+
+// QueryContext 实现 gorm.ConnPool 接口，按配置为 SQL 追加 sqlcommenter 注释
+func (p *gormConnPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return p.ConnPool.QueryContext(ctx, p.comment(ctx, query), args...)
+}
+
+// QueryRowContext 实现 gorm.ConnPool 接口，按配置为 SQL 追加 sqlcommenter 注释
+func (p *gormConnPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.ConnPool.QueryRowContext(ctx, p.comment(ctx, query), args...)
+}
+
+// comment 为 SQL 追加 sqlcommenter 注释
+// 预编译模式下语句按 SQL 缓存复用，追加注释会让缓存失效，因此不追加
+func (p *gormConnPool) comment(ctx context.Context, query string) string {
+	if _, ok := p.ConnPool.(*gorm.PreparedStmtDB); ok {
+		return query
+	}
+	return p.hook.Commenter.comment(ctx, query)
+}
+
+// BeginTx 实现 gorm.ConnPoolBeginner 接口，开启事务
+// db.transaction span 在事务内第一条被追踪的语句执行时才创建，语句全部被过滤的事务不会产生 span
+func (p *gormConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	start := time.Now()
+	var (
+		pool gorm.ConnPool
+		err  error
+	)
+	switch beginner := p.ConnPool.(type) {
+	case gorm.TxBeginner:
+		var tx *sql.Tx
+		if tx, err = beginner.BeginTx(ctx, opts); err == nil {
+			pool = tx
+		}
+	case gorm.ConnPoolBeginner:
+		pool, err = beginner.BeginTx(ctx, opts)
+	default:
+		err = gorm.ErrInvalidTransaction
+	}
+	if err != nil {
+		if p.hook.parentAllowed(ctx) {
+			_, span := p.hook.startSpan(ctx, "db.transaction", trace.WithTimestamp(start))
+			recordError(span, err, p.hook.ErrorClassifier)
+			span.End()
+		}
+		return nil, err
+	}
+
+	// 预编译模式下 GORM 会对 *gorm.PreparedStmtTX 做类型判断（如 SavePoint），只包装其内部的事务
+	if prepared, ok := pool.(*gorm.PreparedStmtTX); ok {
+		prepared.Tx = &gormTx{Tx: prepared.Tx, hook: p.hook, ctx: ctx, start: start}
+		return prepared, nil
+	}
+	tx, ok := pool.(gorm.Tx)
+	if !ok {
+		return pool, nil
+	}
+	return &gormTx{Tx: tx, hook: p.hook, ctx: ctx, start: start, sqlDB: p.sqlDB}, nil
+}
+
+// gormTx 包装 GORM 的事务，事务结束时结束 db.transaction span
+type gormTx struct {
+	gorm.Tx
+	hook  *GormTracingHook
+	ctx   context.Context // 开启事务时的上下文
+	start time.Time       // 开启事务的时间
+	sqlDB *sql.DB
+
+	mu   sync.Mutex
+	span trace.Span // 事务 span，第一条被追踪的语句执行时才创建
+
+	statements atomic.Int64
+	savepoints atomic.Int64
+	once       sync.Once
+}
+
+// gormTxOf 返回连接池对应的事务包装，不在事务中时返回 nil
+func gormTxOf(pool gorm.ConnPool) *gormTx {
+	switch p := pool.(type) {
+	case *gormTx:
+		return p
+	case *gorm.PreparedStmtTX:
+		tx, _ := p.Tx.(*gormTx)
+		return tx
+	default:
+		return nil
+	}
+}
+
+// GetDBConn 实现 gorm.GetDBConnector 接口
+func (t *gormTx) GetDBConn() (*sql.DB, error) {
+	if t.sqlDB != nil {
+		return t.sqlDB, nil
+	}
+	if connector, ok := t.Tx.(gorm.GetDBConnector); ok {
+		return connector.GetDBConn()
+	}
+	return nil, gorm.ErrInvalidDB
+}
+
+// ExecContext 实现 gorm.ConnPool 接口，按配置为 SQL 追加 sqlcommenter 注释
+func (t *gormTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.Tx.ExecContext(ctx, t.hook.Commenter.comment(ctx, query), args...)
+}
+
+// QueryContext 实现 gorm.ConnPool 接口，按配置为 SQL 追加 sqlcommenter 注释
+func (t *gormTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.Tx.QueryContext(ctx, t.hook.Commenter.comment(ctx, query), args...)
+}
+
+// QueryRowContext 实现 gorm.ConnPool 接口，按配置为 SQL 追加 sqlcommenter 注释
+func (t *gormTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return t.Tx.QueryRowContext(ctx, t.hook.Commenter.comment(ctx, query), args...)
+}
+
+// Commit 提交事务并结束 span
+func (t *gormTx) Commit() error {
+	err := t.Tx.Commit()
+	t.end("commit", err)
+	return err
+}
+
+// Rollback 回滚事务并结束 span
+func (t *gormTx) Rollback() error {
+	err := t.Tx.Rollback()
+	t.end("rollback", err)
+	return err
+}
+
+// txSpan 返回事务 span，尚未创建时以开启事务的时间创建
+// 要求父 span 而开启事务时没有父 span 时，返回不记录的 span，事务内的语句也会因此被跳过
+func (t *gormTx) txSpan() trace.Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.span == nil {
+		t.span = noop.Span{}
+		if t.hook.parentAllowed(t.ctx) {
+			_, t.span = t.hook.startSpan(t.ctx, "db.transaction", trace.WithTimestamp(t.start))
+		}
+	}
+	return t.span
+}
+
+// started 返回已创建的事务 span，未创建时返回 nil
+func (t *gormTx) started() trace.Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.span
+}
+
+// parentContext 返回事务内语句的父上下文
+// 语句所在的上下文仍是开启事务时的上下文（或没有 span）时，挂到事务 span 下；
+// 调用方在事务中又创建了自己的 span 时，保留调用方的 span 作为父 span
+func (t *gormTx) parentContext(ctx context.Context) context.Context {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() && !sc.Equal(trace.SpanContextFromContext(t.ctx)) {
+		return ctx
+	}
+	return trace.ContextWithSpan(ctx, t.txSpan())
+}
+
+// observe 记录事务内被追踪的语句，嵌套事务的保存点记录为事件
+// 被过滤规则跳过的语句（包括保存点）不计入 db.transaction.statements
+func (t *gormTx) observe(sql string) {
+	t.statements.Add(1)
+
+	sql = strings.TrimSpace(sql)
+	upper := strings.ToUpper(sql)
+	switch {
+	case strings.HasPrefix(upper, "SAVEPOINT "):
+		t.savepoints.Add(1)
+		t.txSpan().AddEvent("db.savepoint", trace.WithAttributes(
+			attribute.String("db.savepoint.name", strings.TrimSpace(sql[len("SAVEPOINT "):])),
+		))
+	case strings.HasPrefix(upper, "ROLLBACK TO "):
+		name := strings.TrimSpace(sql[len("ROLLBACK TO "):])
+		if strings.HasPrefix(strings.ToUpper(name), "SAVEPOINT ") {
+			name = strings.TrimSpace(name[len("SAVEPOINT "):])
+		}
+		t.txSpan().AddEvent("db.savepoint.rollback", trace.WithAttributes(
+			attribute.String("db.savepoint.name", name),
+		))
+	}
+}
+
+// end 结束事务 span，记录事务结果和语句数量，只生效一次
+// 事务 span 未创建且没有出错时不再补建
+func (t *gormTx) end(outcome string, err error) {
+	t.once.Do(func() {
+		if t.started() == nil && err == nil {
+			return
+		}
+		t.txSpan()
+		t.span.SetAttributes(
+			attribute.String("db.transaction.outcome", outcome),
+			attribute.Int64("db.transaction.statements", t.statements.Load()),
+			attribute.Int64("db.transaction.savepoints", t.savepoints.Load()),
+		)
+		recordError(t.span, err, t.hook.ErrorClassifier)
+		t.span.End()
+	})
+}
@@ -20,81 +20,199 @@ package otelemetry
 
 import (
 	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 )
 
-const redisSpanKey = contextKey("redis_span")
+// 上下文中保存命令和管道 span 的键，节点钩子通过它们找到需要补充属性的 span
+const (
+	redisSpanKey         = contextKey("redis_span")
+	redisPipelineSpanKey = contextKey("redis_pipeline_span")
+)
 
-// RedisHook Redis 的调用链监控钩子
+// RedisHook Redis 的调用链监控钩子，实现 go-redis v9 的 redis.Hook 接口
+// 通过 rdb.AddHook(hook) 或 hook.Instrument(rdb) 安装，每条命令和每个管道都会生成一个 span
 type RedisHook struct {
-	Tracer trace.Tracer
-}
+	Tracer trace.Tracer // 为 nil 时使用 GetTracer("redis")
+
+	// db.statement 的记录方式，默认只记录命令名和 key
+	// AUTH、HELLO、MIGRATE 的参数可能包含密码，无论哪种模式都会被替换为 ?
+	StatementMode RedisStatementMode
+	MaxArgs       int // 截断模式下最多记录的参数个数，<=0 时为 10
+	MaxArgLength  int // 截断模式下每个参数最多记录的字节数，<=0 时为 64
+
+	// ErrorClassifier 错误的记录方式，为 nil 时使用 DefaultErrorClassifier，默认忽略 redis.Nil
+	ErrorClassifier ErrorClassifier
+
+	// PipelineCommands 管道中每条命令的记录方式，默认只记录管道整体
+	// 管道中的命令一起发送，子 span 的时间与管道相同，主要用于定位出错的命令
+	PipelineCommands RedisPipelineMode
 
-// BeforeProcess 在命令执行前创建 span
-func (h *RedisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
-	spanName := "redis." + cmd.Name()
-	ctx, span := h.Tracer.Start(ctx, spanName,
-		trace.WithAttributes(
-			attribute.String("db.system", "redis"),
-			attribute.String("db.operation", cmd.Name()),
-			attribute.String("db.statement", cmd.String()),
-		))
-	return context.WithValue(ctx, redisSpanKey, span), nil
+	// Cache 缓存命中统计配置，为 nil 时不统计
+	// 启用后 GET、MGET、HGET 等读命令的 span 上会记录 cache.hit，配置了 Meter 时还会上报 redis.client.cache.requests
+	Cache *RedisCacheConfig
+
+	// Meter 用于上报命令耗时、建连失败次数等指标，为 nil 时不上报指标
+	// 通过 Instrument 安装时还会上报连接池指标
+	Meter metric.Meter
+	// PoolName 连接池指标的 db.client.connection.pool.name 标签，为空时使用客户端配置的地址
+	PoolName string
+
+	// HandshakeTimeout 自定义 redis.Options.Dialer 返回未握手的 TLS 连接时，在 redis.dial span 内握手的超时时间，<=0 时为 5 秒
+	// go-redis 默认的 Dialer 返回前已在 Options.DialTimeout 内完成握手，不受该字段影响
+	HandshakeTimeout time.Duration
+
+	ringOptions   sync.Map // 调用过 InstrumentRingOptions 的 *redis.RingOptions
+	metricsOnce   sync.Once
+	dialFailures  metric.Int64Counter
+	duration      metric.Float64Histogram
+	cacheRequests metric.Int64Counter
 }
 
-// AfterProcess 在命令执行后结束 span
-func (h *RedisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
-	if span, ok := ctx.Value(redisSpanKey).(trace.Span); ok {
+// DialHook 实现 redis.Hook 接口，为新建连接创建 redis.dial span
+// 自定义 Dialer 返回的 TLS 连接尚未握手时在 span 内握手，握手失败和建连失败一样记录到 span 上并计入 redis.client.dial.failures
+func (h *RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	h.initMetrics()
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		server := redisServerAttributes(addr)
+		ctx, span := h.tracer().Start(ctx, "redis.dial",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String("db.system", "redis"), attribute.String("network.transport", network)),
+			trace.WithAttributes(server...))
 		defer span.End()
-		if err := cmd.Err(); err != nil && err != redis.Nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, err.Error())
+
+		conn, err := next(ctx, network, addr)
+		if tlsConn, ok := conn.(*tls.Conn); ok && err == nil {
+			span.SetAttributes(attribute.Bool("redis.tls", true))
+			if !tlsConn.ConnectionState().HandshakeComplete {
+				if err = h.handshake(ctx, tlsConn); err != nil {
+					_ = conn.Close()
+					conn = nil
+				}
+			}
+		}
+		if err != nil {
+			recordError(span, err, h.ErrorClassifier)
+			if h.dialFailures != nil {
+				h.dialFailures.Add(ctx, 1,
+					metric.WithAttributes(server...),
+					metric.WithAttributes(ErrorTypeKey.String(errorType(err))))
+			}
+			return nil, err
 		}
+		return conn, nil
 	}
-	return nil
 }
 
-// BeforeProcessPipeline 在管道命令执行前创建 span
-func (h *RedisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
-	ctx, span := h.Tracer.Start(ctx, "redis.pipeline",
-		trace.WithAttributes(
-			attribute.String("db.system", "redis"),
-			attribute.String("db.operation", "pipeline"),
-			attribute.Int("db.redis.num_cmd", len(cmds)),
-		))
-	return context.WithValue(ctx, redisSpanKey, span), nil
+// handshake 在 HandshakeTimeout 内完成 TLS 握手
+// 连接池在后台补充连接时使用的上下文没有截止时间，不设置超时握手可能一直阻塞
+func (h *RedisHook) handshake(ctx context.Context, conn *tls.Conn) error {
+	timeout := h.HandshakeTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return conn.HandshakeContext(ctx)
+}
+
+// redisServerAttributes 将 Redis 地址转换为 server.address 和 server.port 属性
+func redisServerAttributes(addr string) []attribute.KeyValue {
+	host, port := splitHostPort(addr)
+	if port == 0 {
+		return []attribute.KeyValue{attribute.String("server.address", addr)}
+	}
+	return []attribute.KeyValue{attribute.String("server.address", host), attribute.Int("server.port", port)}
+}
+
+// ProcessHook 实现 redis.Hook 接口，为单条命令创建 span
+func (h *RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	h.initMetrics()
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		ctx, span := h.tracer().Start(ctx, "redis."+cmd.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "redis"),
+				attribute.String("db.operation", cmd.Name()),
+				attribute.String("db.statement", h.redisStatement(cmd)),
+			))
+		defer span.End()
+		ctx = context.WithValue(ctx, redisSpanKey, span)
+
+		err := next(ctx, cmd)
+		recordError(span, err, h.ErrorClassifier)
+		h.recordCache(ctx, span, cmd, err)
+		h.recordDuration(ctx, cmd.Name(), start, err)
+		return err
+	}
 }
 
-// AfterProcessPipeline 在管道命令执行后结束 span
-func (h *RedisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
-	if span, ok := ctx.Value(redisSpanKey).(trace.Span); ok {
+// ProcessPipelineHook 实现 redis.Hook 接口，为整个管道创建一个 span
+// TxPipeline 的命令被 MULTI/EXEC 包裹，span 名称为 redis.transaction，并带有 db.redis.transaction 属性
+// 管道中的命令逐条设置错误，执行返回的错误只反映网络等整体错误，因此需要检查每条命令
+func (h *RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	h.initMetrics()
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		name, operation, inner := "redis.pipeline", "pipeline", cmds
+		if isRedisTransaction(cmds) {
+			name, operation, inner = "redis.transaction", "multi", cmds[1:len(cmds)-1]
+		}
+		ctx, span := h.tracer().Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "redis"),
+				attribute.String("db.operation", operation),
+				attribute.Int("db.redis.num_cmd", len(inner)),
+			))
 		defer span.End()
-		for _, cmd := range cmds {
-			if err := cmd.Err(); err != nil && err != redis.Nil {
-				span.RecordError(err)
-				span.SetStatus(codes.Error, err.Error())
-				break
-			}
+		ctx = context.WithValue(ctx, redisPipelineSpanKey, span)
+		if operation == "multi" {
+			span.SetAttributes(attribute.Bool("db.redis.transaction", true))
+		}
+		start := time.Now()
+
+		err := next(ctx, cmds)
+		cmdErr := err
+		if cmdErr == nil {
+			cmdErr = h.pipelineError(cmds)
 		}
+		recordError(span, cmdErr, h.ErrorClassifier)
+		h.recordPipelineCommands(ctx, span, inner, start)
+		for _, cmd := range inner {
+			h.recordCache(ctx, nil, cmd, cmd.Err())
+		}
+		h.recordDuration(ctx, operation, start, cmdErr)
+		return err
 	}
-	return nil
 }
 
-// DialHook 实现 v9 版本要求的接口
-func (h *RedisHook) DialHook(next redis.DialHook) redis.DialHook {
-	return next
+// isRedisTransaction 判断管道是否为 MULTI/EXEC 事务
+func isRedisTransaction(cmds []redis.Cmder) bool {
+	return len(cmds) >= 2 && cmds[0].Name() == "multi" && cmds[len(cmds)-1].Name() == "exec"
 }
 
-// ProcessHook 实现 v9 版本要求的接口
-func (h *RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
-	return next
+// tracer 返回创建 span 使用的追踪器
+func (h *RedisHook) tracer() trace.Tracer {
+	if h.Tracer != nil {
+		return h.Tracer
+	}
+	return GetTracer("redis")
 }
 
-// ProcessPipelineHook 实现 v9 版本要求的接口
-func (h *RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
-	return next
+// pipelineError 返回管道中第一个需要记录的命令错误
+func (h *RedisHook) pipelineError(cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && h.ErrorClassifier.Classify(err) != ErrorClassIgnore {
+			return err
+		}
+	}
+	return nil
 }
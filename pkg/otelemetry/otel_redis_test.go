@@ -0,0 +1,645 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// respServer 只支持测试所需命令的 RESP2 服务端
+// HELLO 等未实现的命令返回错误，go-redis 会按不支持 HELLO 的老版本服务端处理
+type respServer struct {
+	ln net.Listener
+
+	mu      sync.Mutex
+	data    map[string]string
+	subs    map[string][]*respConn // 订阅了频道的连接
+	streams map[string]*respStream
+}
+
+// respStream 测试服务端的 Stream，消息 ID 为 <序号>-0
+type respStream struct {
+	entries [][]string // 每条消息的 ID 和字段值
+	groups  map[string]*respGroup
+}
+
+// respGroup 消费组，只记录下一条未投递消息的位置和待确认列表
+type respGroup struct {
+	next    int
+	pending []*respPending // 按首次投递的顺序排列
+}
+
+type respPending struct {
+	index    int // 消息在 entries 中的下标
+	consumer string
+	count    int // 投递次数
+}
+
+// stream 返回 Stream，不存在时创建，调用方需持有 s.mu
+func (s *respServer) stream(key string) *respStream {
+	st, ok := s.streams[key]
+	if !ok {
+		st = &respStream{groups: make(map[string]*respGroup)}
+		s.streams[key] = st
+	}
+	return st
+}
+
+// respEntryIndex 返回消息 ID 对应的下标，- 和 0 返回 -1，+ 返回最大值
+func respEntryIndex(id string) int {
+	if id == "+" {
+		return int(^uint(0) >> 1)
+	}
+	n, _ := strconv.Atoi(strings.SplitN(id, "-", 2)[0])
+	return n - 1
+}
+
+// respEntry 编码一条 Stream 消息
+func respEntry(entry []string) string {
+	reply := "*2\r\n" + respBulk(entry[0]) + fmt.Sprintf("*%d\r\n", len(entry)-1)
+	for _, v := range entry[1:] {
+		reply += respBulk(v)
+	}
+	return reply
+}
+
+// respConn 服务端连接，PUBLISH 会从其他连接的 goroutine 写入订阅者的连接，写入需要加锁
+type respConn struct {
+	net.Conn
+	mu sync.Mutex
+}
+
+func (c *respConn) write(reply string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := io.WriteString(c.Conn, reply)
+	return err
+}
+
+// respBulk 编码 RESP 字符串
+func respBulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+func newRESPServer(t *testing.T) *respServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	return serveRESP(t, ln)
+}
+
+// serveRESP 在已有的监听上启动测试服务端
+func serveRESP(t *testing.T, ln net.Listener) *respServer {
+	s := &respServer{
+		ln:      ln,
+		data:    make(map[string]string),
+		subs:    make(map[string][]*respConn),
+		streams: make(map[string]*respStream),
+	}
+	go s.serve()
+	t.Cleanup(func() { _ = ln.Close() })
+	return s
+}
+
+func (s *respServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(&respConn{Conn: conn})
+	}
+}
+
+// handle 逐条读取命令并写回响应，MULTI 之后的命令缓存到 EXEC 时一起执行
+func (s *respServer) handle(conn *respConn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	var queued [][]string
+	multi := false
+	for {
+		args, err := readRESPCommand(r)
+		if err != nil {
+			return
+		}
+		var reply string
+		switch name := strings.ToUpper(args[0]); {
+		case name == "SUBSCRIBE":
+			s.mu.Lock()
+			for i, channel := range args[1:] {
+				s.subs[channel] = append(s.subs[channel], conn)
+				reply += "*3\r\n" + respBulk("subscribe") + respBulk(channel) + fmt.Sprintf(":%d\r\n", i+1)
+			}
+			s.mu.Unlock()
+		case name == "MULTI":
+			multi, queued, reply = true, nil, "+OK\r\n"
+		case name == "EXEC":
+			reply = fmt.Sprintf("*%d\r\n", len(queued))
+			for _, q := range queued {
+				reply += s.exec(q)
+			}
+			multi, queued = false, nil
+		case multi:
+			queued = append(queued, args)
+			reply = "+QUEUED\r\n"
+		default:
+			reply = s.exec(args)
+		}
+		if err := conn.write(reply); err != nil {
+			return
+		}
+	}
+}
+
+// exec 执行单条命令，返回 RESP 编码的响应
+func (s *respServer) exec(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "SET":
+		s.data[args[1]] = args[2]
+		return "+OK\r\n"
+	case "GET":
+		v, ok := s.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return respBulk(v)
+	case "PUBLISH":
+		subs := s.subs[args[1]]
+		for _, sub := range subs {
+			_ = sub.write("*3\r\n" + respBulk("message") + respBulk(args[1]) + respBulk(args[2]))
+		}
+		return fmt.Sprintf(":%d\r\n", len(subs))
+	case "XADD":
+		// 只支持不带选项的 XADD，忽略调用方指定的 ID
+		st := s.stream(args[1])
+		id := fmt.Sprintf("%d-0", len(st.entries)+1)
+		st.entries = append(st.entries, append([]string{id}, args[3:]...))
+		return respBulk(id)
+	case "XGROUP":
+		if strings.ToUpper(args[1]) != "CREATE" {
+			break
+		}
+		st := s.stream(args[2])
+		g := &respGroup{}
+		if args[4] == "$" {
+			g.next = len(st.entries)
+		}
+		st.groups[args[3]] = g
+		return "+OK\r\n"
+	case "XREADGROUP":
+		return s.xreadgroup(args)
+	case "XPENDING":
+		return s.xpending(args)
+	case "INCR":
+		n, err := strconv.Atoi(s.data[args[1]])
+		if err != nil && s.data[args[1]] != "" {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		n++
+		s.data[args[1]] = strconv.Itoa(n)
+		return fmt.Sprintf(":%d\r\n", n)
+	}
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+}
+
+// xreadgroup 读取新消息（ID 为 >）或当前消费者的待确认消息，读取待确认消息时增加投递次数
+func (s *respServer) xreadgroup(args []string) string {
+	group, consumer, count := args[2], args[3], 0
+	i := 4
+	for ; i < len(args) && strings.ToUpper(args[i]) != "STREAMS"; i++ {
+		switch strings.ToUpper(args[i]) {
+		case "COUNT":
+			count, _ = strconv.Atoi(args[i+1])
+			i++
+		case "BLOCK":
+			i++
+		}
+	}
+	keys := args[i+1:]
+	n := len(keys) / 2
+
+	reply, found := "", 0
+	for k := 0; k < n; k++ {
+		st, ok := s.streams[keys[k]]
+		if !ok || st.groups[group] == nil {
+			return "-NOGROUP No such key or consumer group\r\n"
+		}
+		g := st.groups[group]
+		var entries []string
+		if keys[k+n] == ">" {
+			for g.next < len(st.entries) && (count == 0 || len(entries) < count) {
+				g.pending = append(g.pending, &respPending{index: g.next, consumer: consumer, count: 1})
+				entries = append(entries, respEntry(st.entries[g.next]))
+				g.next++
+			}
+		} else {
+			after := respEntryIndex(keys[k+n])
+			for _, p := range g.pending {
+				if p.consumer == consumer && p.index > after && (count == 0 || len(entries) < count) {
+					p.count++
+					entries = append(entries, respEntry(st.entries[p.index]))
+				}
+			}
+		}
+		if len(entries) > 0 {
+			found++
+			reply += "*2\r\n" + respBulk(keys[k]) + fmt.Sprintf("*%d\r\n", len(entries)) + strings.Join(entries, "")
+		}
+	}
+	if found == 0 {
+		return "*-1\r\n"
+	}
+	return fmt.Sprintf("*%d\r\n", found) + reply
+}
+
+// xpending 返回消费组中 [start, end] 范围内的待确认消息
+func (s *respServer) xpending(args []string) string {
+	st, ok := s.streams[args[1]]
+	if !ok || st.groups[args[2]] == nil {
+		return "-NOGROUP No such key or consumer group\r\n"
+	}
+	i := 3
+	if strings.ToUpper(args[i]) == "IDLE" {
+		i += 2
+	}
+	start, end := respEntryIndex(args[i]), respEntryIndex(args[i+1])
+	count, _ := strconv.Atoi(args[i+2])
+	consumer := ""
+	if len(args) > i+3 {
+		consumer = args[i+3]
+	}
+
+	var entries []string
+	for _, p := range st.groups[args[2]].pending {
+		if p.index < start || p.index > end || consumer != "" && p.consumer != consumer || len(entries) == count {
+			continue
+		}
+		entries = append(entries, "*4\r\n"+respBulk(st.entries[p.index][0])+respBulk(p.consumer)+
+			fmt.Sprintf(":0\r\n:%d\r\n", p.count))
+	}
+	return fmt.Sprintf("*%d\r\n", len(entries)) + strings.Join(entries, "")
+}
+
+// readRESPCommand 读取一条以 RESP 数组发送的命令
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, errors.New("unexpected command format")
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || n <= 0 {
+		return nil, errors.New("invalid array length")
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, errors.New("invalid bulk length")
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+// newTestRedisClient 创建连接到测试服务端、安装了 hook 的客户端
+// 连接在返回前建立好，建连和握手的 span 不会混入测试的断言
+func newTestRedisClient(t *testing.T, hook *RedisHook) (*redis.Client, *tracetest.SpanRecorder, trace.Tracer) {
+	srv := newRESPServer(t)
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	hook.Tracer = tp.Tracer("redis")
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr:            srv.ln.Addr().String(),
+		Protocol:        2,
+		DisableIdentity: true,
+		PoolSize:        1,
+	})
+	t.Cleanup(func() { _ = rdb.Close() })
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	rdb.AddHook(hook)
+	return rdb, sr, tp.Tracer("test")
+}
+
+// checkRedisSpan 检查 span 的父 span、结束时间和状态
+func checkRedisSpan(t *testing.T, s sdktrace.ReadOnlySpan, parent trace.SpanID, before, after time.Time, code codes.Code) {
+	t.Helper()
+	if s.Parent().SpanID() != parent {
+		t.Errorf("%s: parent = %s, want %s", s.Name(), s.Parent().SpanID(), parent)
+	}
+	if s.StartTime().Before(before) || s.EndTime().After(after) || s.EndTime().Before(s.StartTime()) {
+		t.Errorf("%s: span [%v, %v] is not within the call [%v, %v]", s.Name(), s.StartTime(), s.EndTime(), before, after)
+	}
+	if s.Status().Code != code {
+		t.Errorf("%s: status = %v, want %v", s.Name(), s.Status().Code, code)
+	}
+	if v, _ := spanAttr(s, "db.system"); v != "redis" {
+		t.Errorf("%s: db.system = %q, want redis", s.Name(), v)
+	}
+}
+
+// onlySpan 返回唯一一个指定名称的已结束 span
+func onlySpan(t *testing.T, sr *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	spans := endedSpans(sr, name)
+	if len(spans) != 1 {
+		t.Fatalf("got %d %s spans, want 1", len(spans), name)
+	}
+	return spans[0]
+}
+
+func TestRedisHookProcess(t *testing.T) {
+	rdb, sr, tracer := newTestRedisClient(t, &RedisHook{})
+	ctx, root := tracer.Start(context.Background(), "root")
+	defer root.End()
+	rootID := root.SpanContext().SpanID()
+
+	before := time.Now()
+	if err := rdb.Set(ctx, "user:1", "bob", 0).Err(); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	after := time.Now()
+	set := onlySpan(t, sr, "redis.set")
+	checkRedisSpan(t, set, rootID, before, after, codes.Unset)
+	if v, _ := spanAttr(set, "db.operation"); v != "set" {
+		t.Errorf("db.operation = %q, want set", v)
+	}
+
+	// redis.Nil 默认不视为错误
+	before = time.Now()
+	if err := rdb.Get(ctx, "user:2").Err(); !errors.Is(err, redis.Nil) {
+		t.Fatalf("get error = %v, want redis.Nil", err)
+	}
+	checkRedisSpan(t, onlySpan(t, sr, "redis.get"), rootID, before, time.Now(), codes.Unset)
+
+	before = time.Now()
+	if err := rdb.Incr(ctx, "user:1").Err(); err == nil {
+		t.Fatal("incr on a string succeeded")
+	}
+	incr := onlySpan(t, sr, "redis.incr")
+	checkRedisSpan(t, incr, rootID, before, time.Now(), codes.Error)
+	if !strings.Contains(incr.Status().Description, "not an integer") {
+		t.Errorf("status description = %q", incr.Status().Description)
+	}
+}
+
+func TestRedisHookPipeline(t *testing.T) {
+	rdb, sr, tracer := newTestRedisClient(t, &RedisHook{PipelineCommands: RedisPipelineSpans})
+	ctx, root := tracer.Start(context.Background(), "root")
+	defer root.End()
+
+	before := time.Now()
+	cmds, err := rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, "name", "bob", 0)
+		pipe.Incr(ctx, "counter")
+		pipe.Incr(ctx, "name")
+		pipe.Get(ctx, "missing")
+		return nil
+	})
+	after := time.Now()
+	if err == nil {
+		t.Fatal("pipeline with a failing command returned no error")
+	}
+	if len(cmds) != 4 || cmds[1].Err() != nil || cmds[2].Err() == nil {
+		t.Fatalf("unexpected command results: %v", cmds)
+	}
+
+	pipeline := onlySpan(t, sr, "redis.pipeline")
+	checkRedisSpan(t, pipeline, root.SpanContext().SpanID(), before, after, codes.Error)
+	if v, _ := spanAttr(pipeline, "db.redis.num_cmd"); v != "4" {
+		t.Errorf("db.redis.num_cmd = %q, want 4", v)
+	}
+
+	// 每条命令的子 span 挂在管道 span 下，只有出错的命令标记为错误
+	pipelineID := pipeline.SpanContext().SpanID()
+	checkRedisSpan(t, onlySpan(t, sr, "redis.set"), pipelineID, before, after, codes.Unset)
+	checkRedisSpan(t, onlySpan(t, sr, "redis.get"), pipelineID, before, after, codes.Unset)
+	incrs := endedSpans(sr, "redis.incr")
+	if len(incrs) != 2 {
+		t.Fatalf("got %d redis.incr spans, want 2", len(incrs))
+	}
+	for _, s := range incrs {
+		code := codes.Unset
+		if v, _ := spanAttr(s, "db.redis.pipeline.index"); v == "2" {
+			code = codes.Error
+		}
+		checkRedisSpan(t, s, pipelineID, before, after, code)
+	}
+}
+
+func TestRedisHookTxPipeline(t *testing.T) {
+	rdb, sr, tracer := newTestRedisClient(t, &RedisHook{})
+	ctx, root := tracer.Start(context.Background(), "root")
+	defer root.End()
+	rootID := root.SpanContext().SpanID()
+
+	before := time.Now()
+	if _, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, "counter", "1", 0)
+		pipe.Incr(ctx, "counter")
+		return nil
+	}); err != nil {
+		t.Fatalf("tx pipeline: %v", err)
+	}
+	after := time.Now()
+
+	tx := onlySpan(t, sr, "redis.transaction")
+	checkRedisSpan(t, tx, rootID, before, after, codes.Unset)
+	if v, _ := spanAttr(tx, "db.redis.transaction"); v != "true" {
+		t.Errorf("db.redis.transaction = %q, want true", v)
+	}
+	// MULTI 和 EXEC 不计入命令数
+	if v, _ := spanAttr(tx, "db.redis.num_cmd"); v != "2" {
+		t.Errorf("db.redis.num_cmd = %q, want 2", v)
+	}
+	if n := len(sr.Ended()); n != 1 {
+		t.Errorf("got %d spans, the transaction must produce a single span", n)
+	}
+
+	// 事务中的命令在 EXEC 时才执行，出错的命令记录在事务 span 上
+	before = time.Now()
+	if _, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, "name", "bob", 0)
+		pipe.Incr(ctx, "name")
+		return nil
+	}); err == nil {
+		t.Fatal("transaction with a failing command returned no error")
+	}
+	spans := endedSpans(sr, "redis.transaction")
+	if len(spans) != 2 {
+		t.Fatalf("got %d redis.transaction spans, want 2", len(spans))
+	}
+	checkRedisSpan(t, spans[1], rootID, before, time.Now(), codes.Error)
+}
+
+// newDialTestRedisClient 创建未建连的客户端，第一条命令时才通过 hook 建连，不重试
+func newDialTestRedisClient(t *testing.T, hook *RedisHook, opt *redis.Options) (*redis.Client, *tracetest.SpanRecorder) {
+	sr := tracetest.NewSpanRecorder()
+	hook.Tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("redis")
+	opt.Protocol = 2
+	opt.DisableIdentity = true
+	opt.PoolSize = 1
+	opt.MaxRetries = -1
+	rdb := redis.NewClient(opt)
+	t.Cleanup(func() { _ = rdb.Close() })
+	rdb.AddHook(hook)
+	return rdb, sr
+}
+
+// dialSpans 返回已结束的 redis.dial span
+func dialSpans(sr *tracetest.SpanRecorder) []sdktrace.ReadOnlySpan {
+	return endedSpans(sr, "redis.dial")
+}
+
+func TestRedisDialSpan(t *testing.T) {
+	srv := newRESPServer(t)
+	meter := newTestMeter()
+	rdb, sr := newDialTestRedisClient(t, &RedisHook{Meter: meter}, &redis.Options{Addr: srv.ln.Addr().String()})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	spans := dialSpans(sr)
+	if len(spans) != 1 {
+		t.Fatalf("got %d redis.dial spans, want 1", len(spans))
+	}
+	s := spans[0]
+	host, port, _ := net.SplitHostPort(srv.ln.Addr().String())
+	for key, want := range map[string]string{
+		"db.system":         "redis",
+		"network.transport": "tcp",
+		"server.address":    host,
+		"server.port":       port,
+	} {
+		if v, _ := spanAttr(s, key); v != want {
+			t.Errorf("%s = %q, want %q", key, v, want)
+		}
+	}
+	if _, ok := spanAttr(s, "redis.tls"); ok {
+		t.Error("plain connection marked as TLS")
+	}
+	if s.Status().Code != codes.Unset || s.SpanKind() != trace.SpanKindClient {
+		t.Errorf("status = %v, kind = %v", s.Status(), s.SpanKind())
+	}
+	if got := meter.get("redis.client.dial.failures"); len(got) != 0 {
+		t.Errorf("successful dial counted as failure: %+v", got)
+	}
+}
+
+func TestRedisDialFailure(t *testing.T) {
+	// 监听后立即关闭，得到一个拒绝连接的地址
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	meter := newTestMeter()
+	rdb, sr := newDialTestRedisClient(t, &RedisHook{Meter: meter}, &redis.Options{Addr: addr})
+	if err := rdb.Ping(context.Background()).Err(); err == nil {
+		t.Fatal("ping to a closed port succeeded")
+	}
+
+	spans := dialSpans(sr)
+	if len(spans) == 0 {
+		t.Fatal("no redis.dial span for the failed dial")
+	}
+	for _, s := range spans {
+		if s.Status().Code != codes.Error {
+			t.Errorf("failed dial status = %v, want error", s.Status())
+		}
+	}
+	failures := meter.get("redis.client.dial.failures")
+	if len(failures) != len(spans) {
+		t.Fatalf("counted %d failures for %d failed dials", len(failures), len(spans))
+	}
+	if v, _ := failures[0].attrs.Value(ErrorTypeKey); v.AsString() == "" {
+		t.Errorf("failure attributes = %v, want error.type", failures[0].attrs.ToSlice())
+	}
+}
+
+func TestRedisDialTLSHandshakeFailure(t *testing.T) {
+	srv := newRESPServer(t)
+	meter := newTestMeter()
+	// 自定义 Dialer 返回未握手的 TLS 连接，服务端不支持 TLS，握手在 redis.dial span 内失败
+	opt := &redis.Options{
+		Addr: srv.ln.Addr().String(),
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return tls.Client(conn, &tls.Config{InsecureSkipVerify: true}), nil
+		},
+	}
+	rdb, sr := newDialTestRedisClient(t, &RedisHook{Meter: meter, HandshakeTimeout: time.Second}, opt)
+	if err := rdb.Ping(context.Background()).Err(); err == nil {
+		t.Fatal("ping over a failed TLS handshake succeeded")
+	}
+
+	spans := dialSpans(sr)
+	if len(spans) == 0 {
+		t.Fatal("no redis.dial span for the failed handshake")
+	}
+	s := spans[0]
+	if v, _ := spanAttr(s, "redis.tls"); v != "true" {
+		t.Errorf("redis.tls = %q, want true", v)
+	}
+	if s.Status().Code != codes.Error {
+		t.Errorf("handshake failure status = %v, want error", s.Status())
+	}
+	if got := meter.get("redis.client.dial.failures"); len(got) != len(spans) {
+		t.Errorf("counted %d failures for %d failed handshakes", len(got), len(spans))
+	}
+}
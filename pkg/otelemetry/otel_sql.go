@@ -0,0 +1,634 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm/logger"
+)
+
+// SQLTracingConfig database/sql 驱动包装的配置，span 的属性约定与 GormTracingHook 一致
+type SQLTracingConfig struct {
+	Tracer trace.Tracer // 为 nil 时使用 GetTracer("database/sql")
+
+	// 以下字段用于覆盖自动推断的连接信息，为空时从驱动名称和 DSN 中推断
+	// 使用 WrapDriver、WrapConnector 时无法得知驱动名称，需要手动设置 DBSystem
+	DBSystem      string // 数据库类型，如 mysql、postgresql、sqlite
+	DBName        string // 数据库名称
+	ServerAddress string // 数据库地址
+	ServerPort    int    // 数据库端口
+
+	// StatementMode db.statement 的记录方式，默认只记录带占位符的 SQL
+	StatementMode SQLStatementMode
+
+	// Commenter 不为 nil 时在发出的 SQL 末尾追加 sqlcommenter 注释，显式预编译的语句不追加
+	Commenter *SQLCommenter
+
+	// ErrorClassifier 错误的记录方式，为 nil 时使用 DefaultErrorClassifier
+	ErrorClassifier ErrorClassifier
+}
+
+// sqlTracer 单个数据源的追踪器，保存计算好的连接属性
+type sqlTracer struct {
+	cfg    SQLTracingConfig
+	tracer trace.Tracer
+	system string
+	attrs  []attribute.KeyValue
+}
+
+// newSQLTracer 根据配置、驱动名称和 DSN 创建追踪器，手动设置的字段优先
+func newSQLTracer(cfg SQLTracingConfig, driverName, dsn string) *sqlTracer {
+	system := cfg.DBSystem
+	if system == "" {
+		system = dbSystem(driverName)
+	}
+	info := parseDSN(system, dsn)
+	if cfg.DBName != "" {
+		info.dbName = cfg.DBName
+	}
+	if cfg.ServerAddress != "" {
+		info.address = cfg.ServerAddress
+	}
+	if cfg.ServerPort > 0 {
+		info.port = cfg.ServerPort
+	}
+
+	t := &sqlTracer{cfg: cfg, tracer: cfg.Tracer, system: system}
+	if t.tracer == nil {
+		t.tracer = GetTracer("database/sql")
+	}
+	if system != "" {
+		t.attrs = append(t.attrs, attribute.String("db.system", system))
+	}
+	if info.dbName != "" {
+		t.attrs = append(t.attrs, attribute.String("db.name", info.dbName))
+	}
+	if info.address != "" {
+		t.attrs = append(t.attrs, attribute.String("server.address", info.address))
+	}
+	if info.port > 0 {
+		t.attrs = append(t.attrs, attribute.Int("server.port", info.port))
+	}
+	return t
+}
+
+// start 为语句创建 span
+func (t *sqlTracer) start(ctx context.Context, name, query string, args []driver.NamedValue) (context.Context, trace.Span) {
+	attrs := append([]attribute.KeyValue{}, t.attrs...)
+	if query != "" {
+		attrs = append(attrs, attribute.String("db.statement", t.statement(query, args)))
+	}
+	if op := sqlOperation(query); op != "" {
+		attrs = append(attrs, attribute.String("db.operation", op))
+	}
+	return t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+}
+
+// statement 按 StatementMode 生成要记录的 SQL
+func (t *sqlTracer) statement(query string, args []driver.NamedValue) string {
+	switch t.cfg.StatementMode {
+	case SQLStatementFull:
+		vars := make([]interface{}, len(args))
+		for i, arg := range args {
+			vars[i] = arg.Value
+		}
+		return logger.ExplainSQL(query, numericPlaceholder(t.system), `'`, vars...)
+	case SQLStatementObfuscated:
+		return ObfuscateSQL(query, t.system)
+	default:
+		return query
+	}
+}
+
+var (
+	postgresPlaceholder  = regexp.MustCompile(`\$(\d+)`)
+	sqlserverPlaceholder = regexp.MustCompile(`@p(\d+)`)
+)
+
+// numericPlaceholder 返回带序号的占位符格式，使用 ? 占位符的数据库返回 nil
+func numericPlaceholder(system string) *regexp.Regexp {
+	switch system {
+	case "postgresql":
+		return postgresPlaceholder
+	case "mssql":
+		return sqlserverPlaceholder
+	default:
+		return nil
+	}
+}
+
+// sqlSpanName 生成语句 span 的名称，即操作类型
+func sqlSpanName(query string) string {
+	if op := sqlOperation(query); op != "" {
+		return op
+	}
+	return "sql"
+}
+
+// end 按错误分类记录错误并结束 span，driver.ErrSkip 不视为错误
+func (t *sqlTracer) end(span trace.Span, err error) {
+	if !errors.Is(err, driver.ErrSkip) {
+		recordError(span, err, t.cfg.ErrorClassifier)
+	}
+	span.End()
+}
+
+// WrapDriver 包装 database/sql 驱动，为 Query/Exec/Prepare/Begin/Commit/Rollback 创建 span
+// 连接信息在每次打开连接时从 DSN 中解析，数据库类型需要通过 cfg.DBSystem 指定
+func WrapDriver(d driver.Driver, cfg SQLTracingConfig) driver.Driver {
+	return &sqlDriver{Driver: d, cfg: cfg}
+}
+
+// WrapConnector 包装 database/sql 连接器，可配合 sql.OpenDB 使用
+// 连接器不暴露 DSN，连接信息只能通过 cfg 指定
+func WrapConnector(c driver.Connector, cfg SQLTracingConfig) driver.Connector {
+	return &sqlConnector{
+		Connector: c,
+		tracer:    newSQLTracer(cfg, "", ""),
+		driver:    &sqlDriver{Driver: c.Driver(), cfg: cfg},
+	}
+}
+
+// RegisterSQLDriver 将已注册的驱动包装后以 <driverName>-otel 的名称注册，返回新的驱动名称
+// 之后可以直接用 sql.Open 或 sqlx.Open 打开带追踪的连接，重复调用不会重复注册
+func RegisterSQLDriver(driverName string, cfg SQLTracingConfig) (string, error) {
+	name := driverName + "-otel"
+
+	registerMu.Lock()
+	defer registerMu.Unlock()
+	for _, registered := range sql.Drivers() {
+		if registered == name {
+			return name, nil
+		}
+	}
+
+	d, err := lookupDriver(driverName)
+	if err != nil {
+		return "", err
+	}
+	if cfg.DBSystem == "" {
+		cfg.DBSystem = dbSystem(driverName)
+	}
+	sql.Register(name, WrapDriver(d, cfg))
+	return name, nil
+}
+
+// registerMu 保护 RegisterSQLDriver 的检查和注册
+var registerMu sync.Mutex
+
+// OpenSQL 打开带追踪的 *sql.DB，用法与 sql.Open 相同
+// driverName: 已注册的驱动名称，如 mysql、postgres
+func OpenSQL(driverName, dsn string, cfg SQLTracingConfig) (*sql.DB, error) {
+	d, err := lookupDriver(driverName)
+	if err != nil {
+		return nil, err
+	}
+
+	tracer := newSQLTracer(cfg, driverName, dsn)
+	if cfg.DBSystem == "" {
+		cfg.DBSystem = tracer.system
+	}
+	wrapped := &sqlDriver{Driver: d, cfg: cfg}
+
+	var connector driver.Connector = dsnConnector{driver: d, dsn: dsn}
+	if dc, ok := d.(driver.DriverContext); ok {
+		if connector, err = dc.OpenConnector(dsn); err != nil {
+			return nil, fmt.Errorf("open connector failed: %w", err)
+		}
+	}
+	return sql.OpenDB(&sqlConnector{Connector: connector, tracer: tracer, driver: wrapped}), nil
+}
+
+// lookupDriver 查找已注册的驱动
+func lookupDriver(driverName string) (driver.Driver, error) {
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		return nil, fmt.Errorf("lookup driver %s failed: %w", driverName, err)
+	}
+	defer db.Close()
+	return db.Driver(), nil
+}
+
+// dsnConnector 不支持 driver.DriverContext 的驱动使用的连接器
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+// Connect 实现 driver.Connector 接口
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+// Driver 实现 driver.Connector 接口
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// sqlDriver 带追踪的驱动
+type sqlDriver struct {
+	driver.Driver
+	cfg SQLTracingConfig
+}
+
+// Open 实现 driver.Driver 接口
+func (d *sqlDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{Conn: conn, tracer: newSQLTracer(d.cfg, "", dsn)}, nil
+}
+
+// OpenConnector 实现 driver.DriverContext 接口
+func (d *sqlDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	var connector driver.Connector = dsnConnector{driver: d.Driver, dsn: dsn}
+	if dc, ok := d.Driver.(driver.DriverContext); ok {
+		var err error
+		if connector, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+	return &sqlConnector{Connector: connector, tracer: newSQLTracer(d.cfg, "", dsn), driver: d}, nil
+}
+
+// sqlConnector 带追踪的连接器
+type sqlConnector struct {
+	driver.Connector
+	tracer *sqlTracer
+	driver driver.Driver
+}
+
+// Connect 实现 driver.Connector 接口
+func (c *sqlConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{Conn: conn, tracer: c.tracer}, nil
+}
+
+// Driver 实现 driver.Connector 接口
+func (c *sqlConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// skippedSpan 驱动返回 driver.ErrSkip 时暂存的 span
+// database/sql 会在同一个连接上改用预编译语句重试，重试时沿用该 span，避免一条语句产生多个 span
+type skippedSpan struct {
+	ctx   context.Context
+	span  trace.Span
+	query string
+}
+
+// sqlConn 带追踪的连接
+// database/sql 保证同一时刻只有一个 goroutine 使用连接，连接上的状态不需要加锁
+type sqlConn struct {
+	driver.Conn
+	tracer  *sqlTracer
+	tx      *sqlTx       // 连接上未结束的事务
+	skipped *skippedSpan // 等待预编译重试的 span
+}
+
+// startSpan 为连接上的语句创建 span，事务中的语句挂到事务 span 下
+func (c *sqlConn) startSpan(ctx context.Context, name, query string, args []driver.NamedValue) (context.Context, trace.Span) {
+	c.flushSkipped()
+	if c.tx != nil {
+		ctx = c.tx.parentContext(ctx)
+		c.tx.statements.Add(1)
+	}
+	return c.tracer.start(ctx, name, query, args)
+}
+
+// takeSkipped 取出与 query 对应的暂存 span，不对应时结束暂存的 span
+func (c *sqlConn) takeSkipped(query string) *skippedSpan {
+	s := c.skipped
+	c.skipped = nil
+	if s != nil && s.query != query {
+		s.span.End()
+		return nil
+	}
+	return s
+}
+
+// flushSkipped 结束没有被重试的暂存 span
+func (c *sqlConn) flushSkipped() {
+	if c.skipped != nil {
+		c.skipped.span.End()
+		c.skipped = nil
+	}
+}
+
+// finish 结束语句 span，驱动返回 driver.ErrSkip 时暂存 span 等待重试
+func (c *sqlConn) finish(ctx context.Context, span trace.Span, query string, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		c.skipped = &skippedSpan{ctx: ctx, span: span, query: query}
+		return
+	}
+	c.tracer.end(span, err)
+}
+
+// ExecContext 实现 driver.ExecerContext 接口
+func (c *sqlConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.startSpan(ctx, sqlSpanName(query), query, args)
+	res, err := execer.ExecContext(ctx, c.tracer.cfg.Commenter.comment(ctx, query), args)
+	if err == nil {
+		setRowsAffected(span, res)
+	}
+	c.finish(ctx, span, query, err)
+	return res, err
+}
+
+// QueryContext 实现 driver.QueryerContext 接口
+func (c *sqlConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.startSpan(ctx, sqlSpanName(query), query, args)
+	rows, err := queryer.QueryContext(ctx, c.tracer.cfg.Commenter.comment(ctx, query), args)
+	c.finish(ctx, span, query, err)
+	return rows, err
+}
+
+// Prepare 实现 driver.Conn 接口
+func (c *sqlConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext 实现 driver.ConnPrepareContext 接口
+// 由 driver.ErrSkip 触发的隐式预编译不单独创建 span，执行时沿用之前的 span
+// 隐式预编译的语句只执行一次，可以追加 sqlcommenter 注释
+func (c *sqlConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if skipped := c.takeSkipped(query); skipped != nil {
+		stmt, err := c.prepare(skipped.ctx, c.tracer.cfg.Commenter.comment(skipped.ctx, query))
+		if err != nil {
+			c.tracer.end(skipped.span, err)
+			return nil, err
+		}
+		return &sqlStmt{Stmt: stmt, conn: c, query: query, pending: skipped}, nil
+	}
+
+	ctx, span := c.startSpan(ctx, "PREPARE", query, nil)
+	stmt, err := c.prepare(ctx, query)
+	c.tracer.end(span, err)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlStmt{Stmt: stmt, conn: c, query: query}, nil
+}
+
+func (c *sqlConn) prepare(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+// Begin 实现 driver.Conn 接口
+func (c *sqlConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx 实现 driver.ConnBeginTx 接口，开启事务并创建 db.transaction span
+func (c *sqlConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.flushSkipped()
+	parent := trace.SpanContextFromContext(ctx)
+	ctx, span := c.tracer.start(ctx, "db.transaction", "", nil)
+
+	var (
+		tx  driver.Tx
+		err error
+	)
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) || opts.ReadOnly {
+		err = errors.New("sql: driver does not support non-default isolation level or read-only transactions")
+	} else {
+		tx, err = c.Conn.Begin() // 驱动不支持 ConnBeginTx 时的兼容路径
+	}
+	if err != nil {
+		c.tracer.end(span, err)
+		return nil, err
+	}
+
+	c.tx = &sqlTx{Tx: tx, conn: c, span: span, parent: parent}
+	return c.tx, nil
+}
+
+// Ping 实现 driver.Pinger 接口
+func (c *sqlConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession 实现 driver.SessionResetter 接口
+func (c *sqlConn) ResetSession(ctx context.Context) error {
+	c.flushSkipped()
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid 实现 driver.Validator 接口
+func (c *sqlConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue 实现 driver.NamedValueChecker 接口
+func (c *sqlConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// Close 实现 driver.Conn 接口
+func (c *sqlConn) Close() error {
+	c.flushSkipped()
+	return c.Conn.Close()
+}
+
+// sqlStmt 带追踪的预编译语句
+type sqlStmt struct {
+	driver.Stmt
+	conn    *sqlConn
+	query   string
+	pending *skippedSpan // 隐式预编译时沿用的 span，第一次执行时使用
+}
+
+// startSpan 为语句的一次执行创建 span，隐式预编译时沿用之前的 span
+func (s *sqlStmt) startSpan(ctx context.Context, args []driver.NamedValue) (context.Context, trace.Span) {
+	if p := s.pending; p != nil {
+		s.pending = nil
+		return p.ctx, p.span
+	}
+	return s.conn.startSpan(ctx, sqlSpanName(s.query), s.query, args)
+}
+
+// ExecContext 实现 driver.StmtExecContext 接口
+func (s *sqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, span := s.startSpan(ctx, args)
+	var (
+		res driver.Result
+		err error
+	)
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = execer.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			res, err = s.Stmt.Exec(values) // 驱动不支持 StmtExecContext 时的兼容路径
+		}
+	}
+	if err == nil {
+		setRowsAffected(span, res)
+	}
+	s.conn.tracer.end(span, err)
+	return res, err
+}
+
+// QueryContext 实现 driver.StmtQueryContext 接口
+func (s *sqlStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, span := s.startSpan(ctx, args)
+	var (
+		rows driver.Rows
+		err  error
+	)
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			rows, err = s.Stmt.Query(values) // 驱动不支持 StmtQueryContext 时的兼容路径
+		}
+	}
+	s.conn.tracer.end(span, err)
+	return rows, err
+}
+
+// CheckNamedValue 实现 driver.NamedValueChecker 接口
+// database/sql 优先使用语句上的检查器，语句不支持时交给连接的检查器
+func (s *sqlStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+// Close 实现 driver.Stmt 接口，隐式预编译的语句没有执行就关闭时结束沿用的 span
+func (s *sqlStmt) Close() error {
+	if p := s.pending; p != nil {
+		s.pending = nil
+		p.span.End()
+	}
+	return s.Stmt.Close()
+}
+
+// sqlTx 带追踪的事务，事务结束时结束 db.transaction span
+type sqlTx struct {
+	driver.Tx
+	conn   *sqlConn
+	span   trace.Span
+	parent trace.SpanContext // 开启事务时的父 span
+
+	statements atomic.Int64
+	once       sync.Once
+}
+
+// parentContext 返回事务内语句的父上下文，规则与 GORM 事务相同
+func (t *sqlTx) parentContext(ctx context.Context) context.Context {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() && !sc.Equal(t.parent) {
+		return ctx
+	}
+	return trace.ContextWithSpan(ctx, t.span)
+}
+
+// Commit 提交事务并结束 span
+func (t *sqlTx) Commit() error {
+	err := t.Tx.Commit()
+	t.end("commit", err)
+	return err
+}
+
+// Rollback 回滚事务并结束 span
+func (t *sqlTx) Rollback() error {
+	err := t.Tx.Rollback()
+	t.end("rollback", err)
+	return err
+}
+
+// end 结束事务 span，记录事务结果和语句数量，只生效一次
+func (t *sqlTx) end(outcome string, err error) {
+	t.once.Do(func() {
+		t.conn.flushSkipped()
+		if t.conn.tx == t {
+			t.conn.tx = nil
+		}
+		t.span.SetAttributes(
+			attribute.String("db.transaction.outcome", outcome),
+			attribute.Int64("db.transaction.statements", t.statements.Load()),
+		)
+		t.conn.tracer.end(t.span, err)
+	})
+}
+
+// setRowsAffected 记录影响行数，驱动不支持时忽略
+func setRowsAffected(span trace.Span, res driver.Result) {
+	if res == nil {
+		return
+	}
+	if n, err := res.RowsAffected(); err == nil {
+		span.SetAttributes(attribute.Int64("db.rows_affected", n))
+	}
+}
+
+// namedValues 将 driver.NamedValue 转换为旧接口使用的 driver.Value，不支持命名参数
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("sql: driver does not support the use of Named Parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,373 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errTestQuery = errors.New("test query failed")
+
+// testDriver 测试用的驱动，带参数的语句返回 driver.ErrSkip，迫使 database/sql 改用预编译语句
+type testDriver struct{}
+
+func (testDriver) Open(string) (driver.Conn, error) { return &testConn{skip: true}, nil }
+
+// testConnector 测试用的连接器
+type testConnector struct{ skip bool }
+
+func (c testConnector) Connect(context.Context) (driver.Conn, error) {
+	return &testConn{skip: c.skip}, nil
+}
+
+func (testConnector) Driver() driver.Driver { return testDriver{} }
+
+// testConn 测试用的连接，SQL 中包含 fail 时返回错误
+type testConn struct{ skip bool }
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) { return &testStmt{query: query}, nil }
+func (c *testConn) Close() error                              { return nil }
+func (c *testConn) Begin() (driver.Tx, error)                 { return testTx{}, nil }
+
+func (c *testConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c.skip && len(args) > 0 {
+		return nil, driver.ErrSkip
+	}
+	if strings.Contains(query, "fail") {
+		return nil, errTestQuery
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *testConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.skip && len(args) > 0 {
+		return nil, driver.ErrSkip
+	}
+	if strings.Contains(query, "fail") {
+		return nil, errTestQuery
+	}
+	return &testRows{}, nil
+}
+
+type testTx struct{}
+
+func (testTx) Commit() error   { return nil }
+func (testTx) Rollback() error { return nil }
+
+type testStmt struct{ query string }
+
+func (s *testStmt) Close() error  { return nil }
+func (s *testStmt) NumInput() int { return -1 }
+
+func (s *testStmt) Exec([]driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "fail") {
+		return nil, errTestQuery
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *testStmt) Query([]driver.Value) (driver.Rows, error) {
+	if strings.Contains(s.query, "fail") {
+		return nil, errTestQuery
+	}
+	return &testRows{}, nil
+}
+
+// testRows 只返回一行 id=1
+type testRows struct{ done bool }
+
+func (r *testRows) Columns() []string { return []string{"id"} }
+func (r *testRows) Close() error      { return nil }
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func init() {
+	sql.Register("otelemetry-test", testDriver{})
+}
+
+// newTestSQLDB 打开带追踪的测试数据库，返回记录 span 的 recorder 和用于创建父 span 的 tracer
+func newTestSQLDB(t *testing.T, skip bool) (*sql.DB, *tracetest.SpanRecorder, trace.Tracer) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	db := sql.OpenDB(WrapConnector(testConnector{skip: skip}, SQLTracingConfig{
+		Tracer:   tp.Tracer("database/sql"),
+		DBSystem: "mysql",
+	}))
+	// 只保留一个连接，保证事务内外的语句使用同一个连接
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+	return db, sr, tp.Tracer("test")
+}
+
+// endedSpans 按名称返回已结束的 span
+func endedSpans(sr *tracetest.SpanRecorder, name string) []sdktrace.ReadOnlySpan {
+	var spans []sdktrace.ReadOnlySpan
+	for _, s := range sr.Ended() {
+		if s.Name() == name {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+func spanAttr(s sdktrace.ReadOnlySpan, key string) (string, bool) {
+	for _, kv := range s.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value.Emit(), true
+		}
+	}
+	return "", false
+}
+
+func TestSQLErrSkipCreatesOneSpanPerStatement(t *testing.T) {
+	db, sr, tracer := newTestSQLDB(t, true)
+	ctx, root := tracer.Start(context.Background(), "root")
+
+	if _, err := db.ExecContext(ctx, "UPDATE users SET name = ? WHERE id = ?", "bob", 1); err != nil {
+		t.Fatalf("exec: %v", err)
+	}
+	var id int
+	if err := db.QueryRowContext(ctx, "SELECT id FROM users WHERE id = ?", 1).Scan(&id); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "DELETE FROM fail WHERE id = ?", 1); !errors.Is(err, errTestQuery) {
+		t.Fatalf("exec error = %v, want %v", err, errTestQuery)
+	}
+	root.End()
+
+	if spans := endedSpans(sr, "PREPARE"); len(spans) != 0 {
+		t.Fatalf("implicit prepare created %d PREPARE spans", len(spans))
+	}
+	if n := len(sr.Started()); n != 4 {
+		t.Fatalf("started %d spans, want 4 (root and one per statement)", n)
+	}
+	for _, name := range []string{"UPDATE", "SELECT", "DELETE"} {
+		spans := endedSpans(sr, name)
+		if len(spans) != 1 {
+			t.Fatalf("%s: got %d spans, want 1", name, len(spans))
+		}
+		s := spans[0]
+		if s.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("%s: parent = %s, want root", name, s.Parent().SpanID())
+		}
+		if s.EndTime().Before(s.StartTime()) {
+			t.Errorf("%s: ended before it started", name)
+		}
+		wantCode := codes.Unset
+		if name == "DELETE" {
+			wantCode = codes.Error
+		}
+		if s.Status().Code != wantCode {
+			t.Errorf("%s: status = %v, want %v", name, s.Status().Code, wantCode)
+		}
+	}
+	update := endedSpans(sr, "UPDATE")[0]
+	if v, _ := spanAttr(update, "db.rows_affected"); v != "1" {
+		t.Errorf("db.rows_affected = %q, want 1", v)
+	}
+}
+
+func TestSQLSkippedSpanEndedWhenNotRetried(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	conn := &sqlConn{
+		Conn:   &testConn{skip: true},
+		tracer: newSQLTracer(SQLTracingConfig{Tracer: tp.Tracer("database/sql")}, "", ""),
+	}
+	args := []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}
+
+	// 重试的是另一条语句时，暂存的 span 直接结束，新语句单独创建 PREPARE span
+	if _, err := conn.ExecContext(context.Background(), "UPDATE a SET b = ?", args); !errors.Is(err, driver.ErrSkip) {
+		t.Fatalf("exec error = %v, want driver.ErrSkip", err)
+	}
+	if len(sr.Ended()) != 0 {
+		t.Fatal("skipped span ended before the retry")
+	}
+	stmt, err := conn.PrepareContext(context.Background(), "SELECT 1")
+	if err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	_ = stmt.Close()
+	if n := len(endedSpans(sr, "UPDATE")); n != 1 {
+		t.Fatalf("discarded skipped span ended %d times, want 1", n)
+	}
+	if n := len(endedSpans(sr, "PREPARE")); n != 1 {
+		t.Fatalf("got %d PREPARE spans, want 1", n)
+	}
+	if s := endedSpans(sr, "UPDATE")[0]; s.Status().Code != codes.Unset {
+		t.Errorf("discarded span status = %v, driver.ErrSkip must not be recorded", s.Status().Code)
+	}
+
+	// 隐式预编译的语句没有执行就关闭时结束沿用的 span
+	if _, err := conn.QueryContext(context.Background(), "SELECT id FROM t WHERE id = ?", args); !errors.Is(err, driver.ErrSkip) {
+		t.Fatalf("query error = %v, want driver.ErrSkip", err)
+	}
+	if stmt, err = conn.PrepareContext(context.Background(), "SELECT id FROM t WHERE id = ?"); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	_ = stmt.Close()
+	if n := len(endedSpans(sr, "SELECT")); n != 1 {
+		t.Fatalf("closed statement ended %d SELECT spans, want 1", n)
+	}
+
+	// 连接归还或关闭时结束没有被重试的 span
+	_, _ = conn.ExecContext(context.Background(), "DELETE FROM t WHERE id = ?", args)
+	if err := conn.ResetSession(context.Background()); err != nil {
+		t.Fatalf("reset session: %v", err)
+	}
+	if n := len(endedSpans(sr, "DELETE")); n != 1 {
+		t.Fatalf("reset session ended %d DELETE spans, want 1", n)
+	}
+	_, _ = conn.ExecContext(context.Background(), "INSERT INTO t VALUES (?)", args)
+	_ = conn.Close()
+	if n := len(endedSpans(sr, "INSERT")); n != 1 {
+		t.Fatalf("close ended %d INSERT spans, want 1", n)
+	}
+}
+
+func TestSQLTransactionParenting(t *testing.T) {
+	for _, skip := range []bool{false, true} {
+		db, sr, tracer := newTestSQLDB(t, skip)
+		ctx, root := tracer.Start(context.Background(), "root")
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatalf("skip=%v begin: %v", skip, err)
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO users VALUES (?)", 1); err != nil {
+			t.Fatalf("skip=%v insert: %v", skip, err)
+		}
+		// 调用方在事务中创建的 span 保留为语句的父 span
+		inner, child := tracer.Start(ctx, "child")
+		if _, err := tx.ExecContext(inner, "UPDATE users SET name = ? WHERE id = ?", "bob", 1); err != nil {
+			t.Fatalf("skip=%v update: %v", skip, err)
+		}
+		child.End()
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("skip=%v commit: %v", skip, err)
+		}
+
+		tx, err = db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatalf("skip=%v begin: %v", skip, err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM users WHERE id = ?", 1); err != nil {
+			t.Fatalf("skip=%v delete: %v", skip, err)
+		}
+		if err := tx.Rollback(); err != nil {
+			t.Fatalf("skip=%v rollback: %v", skip, err)
+		}
+		// 事务结束后的语句不再挂到事务 span 下
+		if _, err := db.ExecContext(ctx, "SELECT 1"); err != nil {
+			t.Fatalf("skip=%v select: %v", skip, err)
+		}
+		root.End()
+
+		txs := endedSpans(sr, "db.transaction")
+		if len(txs) != 2 {
+			t.Fatalf("skip=%v: got %d transaction spans, want 2", skip, len(txs))
+		}
+		for i, want := range []struct {
+			outcome    string
+			statements string
+		}{{"commit", "2"}, {"rollback", "1"}} {
+			s := txs[i]
+			if s.Parent().SpanID() != root.SpanContext().SpanID() {
+				t.Errorf("skip=%v %s: transaction is not a child of root", skip, want.outcome)
+			}
+			if v, _ := spanAttr(s, "db.transaction.outcome"); v != want.outcome {
+				t.Errorf("skip=%v: outcome = %q, want %q", skip, v, want.outcome)
+			}
+			if v, _ := spanAttr(s, "db.transaction.statements"); v != want.statements {
+				t.Errorf("skip=%v %s: statements = %q, want %q", skip, want.outcome, v, want.statements)
+			}
+		}
+
+		parents := map[string]trace.SpanID{
+			"INSERT": txs[0].SpanContext().SpanID(),
+			"UPDATE": child.SpanContext().SpanID(),
+			"DELETE": txs[1].SpanContext().SpanID(),
+			"SELECT": root.SpanContext().SpanID(),
+		}
+		for name, parent := range parents {
+			spans := endedSpans(sr, name)
+			if len(spans) != 1 {
+				t.Fatalf("skip=%v %s: got %d spans, want 1", skip, name, len(spans))
+			}
+			if spans[0].Parent().SpanID() != parent {
+				t.Errorf("skip=%v %s: parent = %s, want %s", skip, name, spans[0].Parent().SpanID(), parent)
+			}
+		}
+	}
+}
+
+func TestRegisterSQLDriver(t *testing.T) {
+	name, err := RegisterSQLDriver("otelemetry-test", SQLTracingConfig{})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if name != "otelemetry-test-otel" {
+		t.Fatalf("name = %q, want otelemetry-test-otel", name)
+	}
+	// 重复注册直接返回已注册的名称，sql.Register 重复注册会 panic
+	again, err := RegisterSQLDriver("otelemetry-test", SQLTracingConfig{})
+	if err != nil || again != name {
+		t.Fatalf("second register = %q, %v, want %q", again, err, name)
+	}
+	count := 0
+	for _, registered := range sql.Drivers() {
+		if registered == name {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Fatalf("%s registered %d times", name, count)
+	}
+
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	if _, ok := db.Driver().(*sqlDriver); !ok {
+		t.Fatalf("driver = %T, want *sqlDriver", db.Driver())
+	}
+
+	if _, err := RegisterSQLDriver("otelemetry-missing", SQLTracingConfig{}); err == nil {
+		t.Fatal("registering an unknown driver succeeded")
+	}
+}
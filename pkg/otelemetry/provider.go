@@ -22,6 +22,8 @@ package otelemetry
 import (
 	"context"
 	"fmt"
+	"log"
+	"sort"
 	"sync"
 
 	"go.opentelemetry.io/otel"
@@ -42,7 +44,17 @@ type Protocol = ExportProtocol
 type OTelProvider struct {
 	opts           *options
 	tracerProvider *sdktrace.TracerProvider // 追踪提供者实例
+	tailSampler    *TailSamplingProcessor   // 尾部采样处理器，未启用时为 nil
+	remoteSampler  *remoteSampler           // 远程采样器，未启用时为 nil
+	debugSampler   *debugSampler            // 调试采样器，未启用时为 nil
+	processor      sdktrace.SpanProcessor   // 导出管道，虚拟服务与主服务共用
+	sampler        sdktrace.Sampler         // 采样器，虚拟服务与主服务共用
+	parent         *OTelProvider            // 虚拟服务所属的主服务，主服务为 nil
+	name           string                   // 虚拟服务注册的名称
 	once           sync.Once
+
+	virtualsMu sync.Mutex
+	virtuals   []*OTelProvider // 主服务创建的虚拟服务，主服务关闭时一并关闭
 }
 
 var (
@@ -176,53 +188,242 @@ func (p *OTelProvider) createResource() (*resource.Resource, error) {
 // createTracerProvider 创建追踪提供者实例
 // 配置如何收集、处理和导出追踪数据
 func (p *OTelProvider) createTracerProvider(exp sdktrace.SpanExporter, res *resource.Resource) *sdktrace.TracerProvider {
+	// 1. 配置批处理导出器
+	var processor sdktrace.SpanProcessor = sdktrace.NewBatchSpanProcessor(exp,
+		// 一次最多导出多少条数据
+		// 比如设置为 512，就是凑够 512 条数据就会导出一次
+		sdktrace.WithMaxExportBatchSize(p.opts.maxExportBatchSize),
+
+		// 多久导出一次数据
+		// 比如设置为 5s，即使数据没凑够 512 条，5s 后也会导出
+		sdktrace.WithBatchTimeout(p.opts.batchTimeout),
+
+		// 最多能缓存多少条待导出的数据
+		// 比如设置为 2048，超过后新的数据就会被丢弃
+		sdktrace.WithMaxQueueSize(p.opts.maxQueueSize),
+
+		// 导出超时时间
+		// 比如设置为 10s，如果导出超时，数据就会被丢弃
+		sdktrace.WithExportTimeout(p.opts.exportTimeout),
+	)
+
+	// 启用尾部采样时，span 先在内存中按 trace 缓存，决策保留后才交给批处理导出器
+	if p.opts.tailSampling {
+		p.tailSampler = NewTailSamplingProcessor(processor, p.opts.tailSamplingOpts...)
+		processor = p.tailSampler
+	}
+	p.processor = processor
+	p.sampler = p.createSampler()
+
 	return sdktrace.NewTracerProvider(
-		// 1. 配置批处理导出器
-		sdktrace.WithBatcher(exp,
-			// 一次最多导出多少条数据
-			// 比如设置为 512，就是凑够 512 条数据就会导出一次
-			sdktrace.WithMaxExportBatchSize(p.opts.maxExportBatchSize),
-
-			// 多久导出一次数据
-			// 比如设置为 5s，即使数据没凑够 512 条，5s 后也会导出
-			sdktrace.WithBatchTimeout(p.opts.batchTimeout),
-
-			// 最多能缓存多少条待导出的数据
-			// 比如设置为 2048，超过后新的数据就会被丢弃
-			sdktrace.WithMaxQueueSize(p.opts.maxQueueSize),
-
-			// 导出超时时间
-			// 比如设置为 10s，如果导出超时，数据就会被丢弃
-			sdktrace.WithExportTimeout(p.opts.exportTimeout),
-		),
+		sdktrace.WithSpanProcessor(processor),
 
 		// 2. 设置资源属性
 		// 就像前面说的快递单，每条数据都会带上这些标签
 		sdktrace.WithResource(res),
 
 		// 3. 配置采样策略
-		sdktrace.WithSampler(
-			// 使用基于父 Span 的采样策略
-			sdktrace.ParentBased(
-				// 设置采样比例
-				// samplingRatio 范围是 0-1
-				// 0 表示不采样，1 表示全采样
-				// 0.1 表示采样 10% 的数据
-				sdktrace.TraceIDRatioBased(p.opts.samplingRatio),
-			),
-		),
+		sdktrace.WithSampler(p.sampler),
 	)
 }
 
+// createSampler 创建采样器
+// 使用基于父 Span 的采样策略，只有根 span 才由这里配置的采样器决定
+func (p *OTelProvider) createSampler() sdktrace.Sampler {
+	root := p.createRootSampler()
+
+	// 配置了远程采样时，优先使用远程下发的策略，本地采样器作为兜底
+	if p.opts.remoteSamplingURL != "" {
+		p.remoteSampler = newRemoteSampler(p.opts.remoteSamplingURL, p.opts.serviceName,
+			p.opts.remoteSamplingInterval, p.opts.timeout, root)
+		root = p.remoteSampler
+	}
+
+	sampler := sdktrace.ParentBased(root)
+
+	// 配置了调试采样时，带有调试标记的请求无视父 span 和采样率直接采样
+	if p.opts.debugSampling {
+		p.debugSampler = newDebugSampler(sampler, p.opts.debugHeader, p.opts.debugBaggageKey, p.opts.debugSecret)
+		sampler = p.debugSampler
+	}
+	return sampler
+}
+
+// createRootSampler 创建本地配置的根 span 采样器
+func (p *OTelProvider) createRootSampler() sdktrace.Sampler {
+	// 配置了限流时，按令牌桶控制每秒采样的根 trace 数
+	// 令牌耗尽后按 minSamplingRatio 保底采样
+	if p.opts.rateLimitPerSecond > 0 {
+		return newRateLimitSampler(p.opts.rateLimitPerSecond, p.opts.rateLimitBurst, p.opts.minSamplingRatio)
+	}
+
+	// 设置采样比例
+	// samplingRatio 范围是 0-1
+	// 0 表示不采样，1 表示全采样
+	// 0.1 表示采样 10% 的数据
+	return sdktrace.TraceIDRatioBased(p.opts.samplingRatio)
+}
+
 // Tracer 返回指定名称的追踪器
 func (p *OTelProvider) Tracer(name string) trace.Tracer {
 	return p.tracerProvider.Tracer(name)
 }
 
+// TailSampler 返回尾部采样处理器，可用于查看统计信息，未启用尾部采样时返回 nil
+func (p *OTelProvider) TailSampler() *TailSamplingProcessor {
+	return p.tailSampler
+}
+
 // Shutdown 关闭追踪提供者 p.tracerProvider 会被关闭
+// 主服务关闭时会先关闭并注销它创建的所有虚拟服务；虚拟服务关闭时只刷新共用的导出管道，并从注册表中注销
 func (p *OTelProvider) Shutdown(ctx context.Context) error {
+	p.virtualsMu.Lock()
+	virtuals := p.virtuals
+	p.virtuals = nil
+	p.virtualsMu.Unlock()
+
+	var firstErr error
+	for _, v := range virtuals {
+		if err := v.Shutdown(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if p.parent != nil {
+		p.parent.removeVirtual(p)
+		unregisterProvider(p.name, p)
+	}
+	if p.remoteSampler != nil {
+		p.remoteSampler.close()
+	}
+	if p.tracerProvider == nil {
+		return firstErr
+	}
+	if err := p.tracerProvider.Shutdown(ctx); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// removeVirtual 从主服务中移除已关闭的虚拟服务
+func (p *OTelProvider) removeVirtual(v *OTelProvider) {
+	p.virtualsMu.Lock()
+	defer p.virtualsMu.Unlock()
+	for i, existing := range p.virtuals {
+		if existing == v {
+			p.virtuals = append(p.virtuals[:i], p.virtuals[i+1:]...)
+			return
+		}
+	}
+}
+
+// NewVirtualService 基于当前提供者创建一个虚拟服务，并以 name 注册到提供者注册表
+// 虚拟服务与当前提供者共用导出管道和采样器，但拥有独立的资源属性（服务名称、版本）
+// 适用于单体应用中的多个模块，让各模块的 span 在后端显示为独立的服务
+// name: 虚拟服务名称，默认同时作为 service.name
+// opts: 只有服务信息相关的选项（WithServiceName、WithServiceVersion、WithEnvironment）会生效
+func (p *OTelProvider) NewVirtualService(name string, opts ...Option) (*OTelProvider, error) {
 	if p.tracerProvider == nil {
-		return nil
+		return nil, fmt.Errorf("provider not initialized")
+	}
+
+	options := *p.opts
+	options.serviceName = name
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	v := &OTelProvider{
+		opts:         &options,
+		tailSampler:  p.tailSampler,
+		debugSampler: p.debugSampler,
+		processor:    p.processor,
+		sampler:      p.sampler,
+		parent:       p,
+		name:         name,
+	}
+
+	res, err := v.createResource()
+	if err != nil {
+		return nil, fmt.Errorf("create resource failed: %w", err)
+	}
+	v.tracerProvider = sdktrace.NewTracerProvider(
+		// 共用主服务的导出管道，虚拟服务关闭时不会关闭该管道
+		sdktrace.WithSpanProcessor(sharedProcessor{p.processor}),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(p.sampler),
+	)
+
+	p.virtualsMu.Lock()
+	p.virtuals = append(p.virtuals, v)
+	p.virtualsMu.Unlock()
+
+	RegisterProvider(name, v)
+	return v, nil
+}
+
+// sharedProcessor 共用的 span 处理器
+// 关闭时只刷新数据，真正的关闭由主服务负责
+type sharedProcessor struct {
+	sdktrace.SpanProcessor
+}
+
+// Shutdown 实现 sdktrace.SpanProcessor 接口
+func (s sharedProcessor) Shutdown(ctx context.Context) error {
+	return s.SpanProcessor.ForceFlush(ctx)
+}
+
+// 注册的命名提供者
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[string]*OTelProvider)
+)
+
+// RegisterProvider 以 name 注册提供者
+// 注册后 GetTracer(name) 会使用该提供者创建追踪器，GetTracer 返回的追踪器每次创建 span 时都会重新查找提供者，
+// 因此先调用 GetTracer 再注册也能生效
+func RegisterProvider(name string, p *OTelProvider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	if _, exists := providers[name]; exists {
+		log.Printf("Provider %s already registered", name)
+	}
+	providers[name] = p
+}
+
+// UnregisterProvider 注销以 name 注册的提供者，之后 GetTracer(name) 回到全局提供者
+func UnregisterProvider(name string) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	delete(providers, name)
+}
+
+// unregisterProvider 只有 name 仍注册为 p 时才注销，避免误删之后重新注册的同名提供者
+func unregisterProvider(name string, p *OTelProvider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	if providers[name] == p {
+		delete(providers, name)
+	}
+}
+
+// GetProvider 返回以 name 注册的提供者，未注册时返回 nil
+func GetProvider(name string) *OTelProvider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	return providers[name]
+}
+
+// Providers 返回所有已注册的提供者名称，按名称排序
+func Providers() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
 	}
-	return p.tracerProvider.Shutdown(ctx)
+	sort.Strings(names)
+	return names
 }
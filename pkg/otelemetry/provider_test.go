@@ -0,0 +1,159 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// newTestProvider 创建导出管道为 recordingProcessor 的主服务，不连接任何导出端点
+func newTestProvider(t *testing.T) (*OTelProvider, *recordingProcessor) {
+	t.Helper()
+	opts := defaultOptions()
+	opts.serviceName = "main-service"
+	opts.serviceVersion = "1.0.0"
+	opts.environment = "test"
+
+	next := &recordingProcessor{}
+	p := &OTelProvider{opts: opts, processor: next, sampler: sdktrace.AlwaysSample()}
+	res, err := p.createResource()
+	if err != nil {
+		t.Fatalf("create resource: %v", err)
+	}
+	p.tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(next),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(p.sampler),
+	)
+	return p, next
+}
+
+// resourceValue 返回 span 资源属性中 key 的值
+func resourceValue(s sdktrace.ReadOnlySpan, key attribute.Key) string {
+	v, _ := s.Resource().Set().Value(key)
+	return v.Emit()
+}
+
+func TestVirtualServiceResource(t *testing.T) {
+	p, next := newTestProvider(t)
+	defer p.Shutdown(context.Background())
+
+	v, err := p.NewVirtualService("provider-test-orders", WithServiceVersion("2.0.0"))
+	if err != nil {
+		t.Fatalf("NewVirtualService: %v", err)
+	}
+	if GetProvider("provider-test-orders") != v {
+		t.Fatal("virtual service was not registered under its name")
+	}
+
+	_, span := v.Tracer("orders").Start(context.Background(), "order.create")
+	span.End()
+	_, span = p.Tracer("main").Start(context.Background(), "main.op")
+	span.End()
+
+	// 两个服务的 span 进入同一个导出管道，资源属性各自独立
+	if len(next.ended) != 2 {
+		t.Fatalf("shared processor got %d spans, want 2", len(next.ended))
+	}
+	want := map[string][3]string{
+		"order.create": {"provider-test-orders", "2.0.0", "test"},
+		"main.op":      {"main-service", "1.0.0", "test"},
+	}
+	for _, s := range next.ended {
+		got := [3]string{
+			resourceValue(s, semconv.ServiceNameKey),
+			resourceValue(s, semconv.ServiceVersionKey),
+			resourceValue(s, "environment"),
+		}
+		if got != want[s.Name()] {
+			t.Errorf("%s resource = %v, want %v", s.Name(), got, want[s.Name()])
+		}
+	}
+
+	// GetTracer 按名称解析到虚拟服务
+	_, span = GetTracer("provider-test-orders").Start(context.Background(), "via.registry")
+	span.End()
+	if last := next.ended[len(next.ended)-1]; resourceValue(last, semconv.ServiceNameKey) != "provider-test-orders" {
+		t.Errorf("GetTracer span service.name = %q", resourceValue(last, semconv.ServiceNameKey))
+	}
+}
+
+func TestVirtualServiceShutdown(t *testing.T) {
+	p, next := newTestProvider(t)
+
+	v, err := p.NewVirtualService("provider-test-billing")
+	if err != nil {
+		t.Fatalf("NewVirtualService: %v", err)
+	}
+
+	// 虚拟服务单独关闭时只刷新共用管道，并从主服务和注册表中移除
+	if err := v.Shutdown(context.Background()); err != nil {
+		t.Fatalf("virtual Shutdown: %v", err)
+	}
+	if next.shutdown || next.flushed != 1 {
+		t.Fatalf("virtual Shutdown: shutdown=%v flushed=%d, want only a flush", next.shutdown, next.flushed)
+	}
+	if GetProvider("provider-test-billing") != nil || len(p.virtuals) != 0 {
+		t.Fatal("closed virtual service is still registered")
+	}
+
+	// 主服务关闭时一并关闭剩余的虚拟服务，最后关闭导出管道
+	if _, err := p.NewVirtualService("provider-test-audit"); err != nil {
+		t.Fatalf("NewVirtualService: %v", err)
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if !next.shutdown {
+		t.Error("parent Shutdown did not shut down the shared processor")
+	}
+	if GetProvider("provider-test-audit") != nil || len(p.virtuals) != 0 {
+		t.Error("parent Shutdown did not tear down its virtual services")
+	}
+}
+
+func TestVirtualServiceKeepsReplacement(t *testing.T) {
+	p, _ := newTestProvider(t)
+	defer p.Shutdown(context.Background())
+
+	v, err := p.NewVirtualService("provider-test-replaced")
+	if err != nil {
+		t.Fatalf("NewVirtualService: %v", err)
+	}
+	defer UnregisterProvider("provider-test-replaced")
+
+	// 关闭旧的虚拟服务不能注销之后以同名注册的提供者
+	replacement, _ := newTestProvider(t)
+	RegisterProvider("provider-test-replaced", replacement)
+	_ = v.Shutdown(context.Background())
+	if GetProvider("provider-test-replaced") != replacement {
+		t.Error("closing the old virtual service unregistered its replacement")
+	}
+}
+
+func TestNewVirtualServiceUninitialized(t *testing.T) {
+	if _, err := (&OTelProvider{opts: defaultOptions()}).NewVirtualService("x"); err == nil {
+		t.Error("NewVirtualService on an uninitialized provider returned no error")
+	}
+}
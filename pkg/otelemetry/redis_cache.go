@@ -0,0 +1,211 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisCacheConfig 缓存命中统计配置
+// 读命令返回 redis.Nil 或空结果时记为未命中，其余成功的结果记为命中，出错的命令不统计
+// 多个 key 的 EXISTS 只返回存在的个数，无法区分哪个 key 命中，因此只按第一个 key 的模式统计命中和未命中的总数
+type RedisCacheConfig struct {
+	// KeyRules 将 key 归一化为低基数模式的规则，按顺序匹配，第一个匹配的规则生效
+	// 没有规则匹配时按 : 分段，将纯数字、UUID 和长度不小于 16 的十六进制串替换为 *
+	// 如 user:123 归一化为 user:*，其余分段原样保留，邮箱、随机 token 等需要通过规则归一化
+	KeyRules []RedisKeyRule
+}
+
+// RedisKeyRule key 归一化规则
+type RedisKeyRule struct {
+	Pattern     *regexp.Regexp // 匹配 key 的正则
+	Replacement string         // 归一化后的模式，支持 $1 等分组引用
+}
+
+// redisCacheCommands 统计缓存命中的读命令
+var redisCacheCommands = map[string]bool{
+	"get": true, "getex": true, "getdel": true, "mget": true,
+	"hget": true, "hmget": true, "hgetall": true,
+	"lindex": true, "lrange": true, "smembers": true, "zscore": true, "exists": true,
+}
+
+// redisCacheResult 一个 key 的命中结果，多个 key 的 EXISTS 只有一个结果，记录命中和未命中的总数
+type redisCacheResult struct {
+	key    string
+	hits   int
+	misses int
+}
+
+// newRedisCacheResult 返回单个 key 的命中结果
+func newRedisCacheResult(key string, hit bool) redisCacheResult {
+	if hit {
+		return redisCacheResult{key: key, hits: 1}
+	}
+	return redisCacheResult{key: key, misses: 1}
+}
+
+// recordCache 统计读命令的缓存命中情况
+// 单条命令执行完钩子链后才会设置 cmd.Err()，因此由调用方传入 err
+// span 不为 nil 时在 span 上记录 cache.hit 和 cache.key_pattern，MGET、HMGET 等多值命令记录 cache.hits 和 cache.misses
+func (h *RedisHook) recordCache(ctx context.Context, span trace.Span, cmd redis.Cmder, err error) {
+	if h.Cache == nil || !redisCacheCommands[cmd.Name()] {
+		return
+	}
+	results := redisCacheResults(cmd, err)
+	if len(results) == 0 {
+		return
+	}
+
+	hits, misses := 0, 0
+	for _, r := range results {
+		hits += r.hits
+		misses += r.misses
+		if h.cacheRequests != nil {
+			pattern := h.Cache.keyPattern(r.key)
+			h.addCacheRequests(ctx, cmd.Name(), pattern, "hit", r.hits)
+			h.addCacheRequests(ctx, cmd.Name(), pattern, "miss", r.misses)
+		}
+	}
+
+	if span == nil {
+		return
+	}
+	span.SetAttributes(attribute.String("cache.key_pattern", h.Cache.keyPattern(results[0].key)))
+	if hits+misses == 1 {
+		span.SetAttributes(attribute.Bool("cache.hit", hits == 1))
+	} else {
+		span.SetAttributes(attribute.Int("cache.hits", hits), attribute.Int("cache.misses", misses))
+	}
+}
+
+// addCacheRequests 上报 n 次命中或未命中的请求
+func (h *RedisHook) addCacheRequests(ctx context.Context, operation, pattern, result string, n int) {
+	if n == 0 {
+		return
+	}
+	h.cacheRequests.Add(ctx, int64(n), metric.WithAttributes(
+		attribute.String("db.system", "redis"),
+		attribute.String("db.operation", operation),
+		attribute.String("cache.key_pattern", pattern),
+		attribute.String("cache.result", result),
+	))
+}
+
+// redisCacheResults 根据命令结果判断每个 key 是否命中
+// MGET 按 key 统计，HMGET 按字段统计，多个 key 的 EXISTS 只统计总数，其余命令只统计第一个 key
+func redisCacheResults(cmd redis.Cmder, err error) []redisCacheResult {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil
+	}
+	key := redisKey(cmd)
+	if errors.Is(err, redis.Nil) {
+		return []redisCacheResult{newRedisCacheResult(key, false)}
+	}
+
+	switch c := cmd.(type) {
+	case *redis.SliceCmd:
+		args := cmd.Args()
+		results := make([]redisCacheResult, 0, len(c.Val()))
+		for i, v := range c.Val() {
+			k := key
+			if cmd.Name() == "mget" && i+1 < len(args) {
+				k = redisArgString(args[i+1])
+			}
+			results = append(results, newRedisCacheResult(k, v != nil))
+		}
+		return results
+	case *redis.MapStringStringCmd:
+		return []redisCacheResult{newRedisCacheResult(key, len(c.Val()) > 0)}
+	case *redis.StringSliceCmd:
+		return []redisCacheResult{newRedisCacheResult(key, len(c.Val()) > 0)}
+	case *redis.IntCmd:
+		// EXISTS 对重复的 key 会重复计数，存在的个数不会超过参数个数
+		if keys := len(cmd.Args()) - 1; cmd.Name() == "exists" && keys > 1 {
+			hits := int(min(c.Val(), int64(keys)))
+			return []redisCacheResult{{key: key, hits: hits, misses: keys - hits}}
+		}
+		return []redisCacheResult{newRedisCacheResult(key, c.Val() > 0)}
+	case *redis.Cmd:
+		return []redisCacheResult{newRedisCacheResult(key, c.Val() != nil)}
+	}
+	return []redisCacheResult{newRedisCacheResult(key, true)}
+}
+
+// keyPattern 将 key 归一化为低基数模式
+func (c *RedisCacheConfig) keyPattern(key string) string {
+	for _, rule := range c.KeyRules {
+		if rule.Pattern != nil && rule.Pattern.MatchString(key) {
+			return rule.Pattern.ReplaceAllString(key, rule.Replacement)
+		}
+	}
+	segments := strings.Split(key, ":")
+	for i, seg := range segments {
+		if isRedisKeyID(seg) {
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, ":")
+}
+
+// isRedisKeyID 判断 key 的分段是否为 ID：纯数字、UUID 或长度不小于 16 的十六进制串
+// 大小写混合或带版本号的分段（如 checkout-v2、oauth2）可能是固定的名字，不做替换
+func isRedisKeyID(seg string) bool {
+	if seg == "" {
+		return false
+	}
+	digits, hex := true, true
+	for i := 0; i < len(seg); i++ {
+		ch := seg[i]
+		digits = digits && ch >= '0' && ch <= '9'
+		hex = hex && isHexDigit(ch)
+	}
+	return digits || hex && len(seg) >= 16 || isUUID(seg)
+}
+
+// isUUID 判断是否为 8-4-4-4-12 格式的 UUID
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isHexDigit(s[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isHexDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'f' || ch >= 'A' && ch <= 'F'
+}
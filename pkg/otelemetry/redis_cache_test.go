@@ -0,0 +1,128 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRedisKeyPattern(t *testing.T) {
+	cfg := &RedisCacheConfig{KeyRules: []RedisKeyRule{
+		{Pattern: regexp.MustCompile(`^(order):\d+:items$`), Replacement: "$1:*:items"},
+	}}
+	tests := map[string]string{
+		"order:42:items":   "order:*:items",
+		"user:123":         "user:*",
+		"user:123:profile": "user:*:profile",
+		"session:aBcDeF":   "session:aBcDeF",
+		"session:3f2b8c1e-9d4a-4b7e-8c6f-1a2b3c4d5e6f": "session:*",
+		"token:deadbeefcafebabe":                       "token:*",
+		"session:3F2B8C1E-9D4A-4B7E-8C6F-1A2B3C4D5E6F": "session:*",
+		"token:cafe":                "token:cafe",
+		"mail:bob@example.com":      "mail:bob@example.com",
+		"feature_flags:checkout-v2": "feature_flags:checkout-v2",
+		"auth:oauth2:state":         "auth:oauth2:state",
+		"user:camelCase":            "user:camelCase",
+		"config:app.settings":       "config:app.settings",
+		"rate_limit:login":          "rate_limit:login",
+		"":                          "",
+	}
+	for key, want := range tests {
+		if got := cfg.keyPattern(key); got != want {
+			t.Errorf("keyPattern(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestRedisCacheResultsMultiKey(t *testing.T) {
+	ctx := context.Background()
+
+	// 多个 key 的 EXISTS 只知道存在的个数，按第一个 key 记录总数
+	exists := redis.NewIntCmd(ctx, "exists", "user:1", "session:aBcD", "mail:bob@example.com")
+	exists.SetVal(2)
+	results := redisCacheResults(exists, nil)
+	if want := (redisCacheResult{key: "user:1", hits: 2, misses: 1}); len(results) != 1 || results[0] != want {
+		t.Errorf("exists results = %+v, want [%+v]", results, want)
+	}
+
+	// 重复的 key 会被重复计数
+	dup := redis.NewIntCmd(ctx, "exists", "user:1", "user:1")
+	dup.SetVal(2)
+	if want := (redisCacheResult{key: "user:1", hits: 2}); redisCacheResults(dup, nil)[0] != want {
+		t.Errorf("duplicate key exists = %+v, want %+v", redisCacheResults(dup, nil), want)
+	}
+
+	single := redis.NewIntCmd(ctx, "exists", "user:1")
+	single.SetVal(0)
+	if results := redisCacheResults(single, nil); len(results) != 1 || results[0] != (redisCacheResult{key: "user:1", misses: 1}) {
+		t.Errorf("single key exists = %+v, want one miss", results)
+	}
+
+	mget := redis.NewSliceCmd(ctx, "mget", "user:1", "user:2")
+	mget.SetVal([]interface{}{"bob", nil})
+	results = redisCacheResults(mget, nil)
+	if len(results) != 2 || results[0] != (redisCacheResult{key: "user:1", hits: 1}) ||
+		results[1] != (redisCacheResult{key: "user:2", misses: 1}) {
+		t.Errorf("mget results = %+v", results)
+	}
+}
+
+func TestRedisRecordCacheMultiKeyExists(t *testing.T) {
+	ctx := context.Background()
+	meter := newTestMeter()
+	hook := &RedisHook{Cache: &RedisCacheConfig{}}
+	hook.cacheRequests, _ = meter.Int64Counter("redis.client.cache.requests")
+
+	sr := tracetest.NewSpanRecorder()
+	_, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("redis").Start(ctx, "redis.exists")
+	exists := redis.NewIntCmd(ctx, "exists", "user:1", "user:2", "order:3")
+	exists.SetVal(2)
+	hook.recordCache(ctx, span, exists, nil)
+	span.End()
+
+	s := sr.Ended()[0]
+	for key, want := range map[string]string{"cache.hits": "2", "cache.misses": "1", "cache.key_pattern": "user:*"} {
+		if v, _ := spanAttr(s, key); v != want {
+			t.Errorf("%s = %q, want %q", key, v, want)
+		}
+	}
+	if _, ok := spanAttr(s, "cache.hit"); ok {
+		t.Error("multi-key exists recorded cache.hit")
+	}
+
+	// 只上报总数，不为单个 key 编造命中结果
+	got := map[string]float64{}
+	for _, m := range meter.get("redis.client.cache.requests") {
+		result, _ := m.attrs.Value("cache.result")
+		pattern, _ := m.attrs.Value("cache.key_pattern")
+		if pattern.AsString() != "user:*" {
+			t.Errorf("cache.key_pattern = %q, want user:*", pattern.AsString())
+		}
+		got[result.AsString()] += m.value
+	}
+	if got["hit"] != 2 || got["miss"] != 1 || len(meter.get("redis.client.cache.requests")) != 2 {
+		t.Errorf("cache requests = %v, want one hit measurement of 2 and one miss measurement of 1", got)
+	}
+}
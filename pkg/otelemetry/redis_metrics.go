@@ -0,0 +1,162 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// initMetrics 创建命令耗时直方图、建连失败和缓存命中计数器，只执行一次
+func (h *RedisHook) initMetrics() {
+	h.metricsOnce.Do(func() {
+		if h.Meter == nil {
+			return
+		}
+		var err error
+		h.duration, err = h.Meter.Float64Histogram("db.client.operation.duration",
+			metric.WithDescription("数据库语句的执行耗时"),
+			metric.WithUnit("s"),
+			metric.WithExplicitBucketBoundaries(0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10))
+		if err != nil {
+			log.Printf("create redis metrics failed: %v", err)
+		}
+		h.dialFailures, err = h.Meter.Int64Counter("redis.client.dial.failures",
+			metric.WithDescription("Redis 建连失败的次数"),
+			metric.WithUnit("{failure}"))
+		if err != nil {
+			log.Printf("create redis metrics failed: %v", err)
+		}
+		if h.Cache != nil {
+			h.cacheRequests, err = h.Meter.Int64Counter("redis.client.cache.requests",
+				metric.WithDescription("Redis 缓存读命令的次数，按 cache.result 区分命中和未命中"),
+				metric.WithUnit("{request}"))
+			if err != nil {
+				log.Printf("create redis metrics failed: %v", err)
+			}
+		}
+	})
+}
+
+// recordDuration 按命令名和结果记录命令耗时
+// 结果 db.redis.outcome 取值为 ok、nil（key 不存在）或 error，出错时还会带上 error.type
+func (h *RedisHook) recordDuration(ctx context.Context, operation string, start time.Time, err error) {
+	if h.duration == nil {
+		return
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "redis"),
+		attribute.String("db.operation", operation),
+	}
+	switch {
+	case err == nil:
+		attrs = append(attrs, attribute.String("db.redis.outcome", "ok"))
+	case errors.Is(err, redis.Nil):
+		attrs = append(attrs, attribute.String("db.redis.outcome", "nil"))
+	default:
+		attrs = append(attrs, attribute.String("db.redis.outcome", "error"), ErrorTypeKey.String(errorType(err)))
+	}
+	h.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+}
+
+// registerPoolMetrics 注册连接池指标，采集时从 PoolStats() 读取
+// 集群和 Ring 客户端的 PoolStats() 是所有节点连接池的累加值
+func (h *RedisHook) registerPoolMetrics(rdb redis.UniversalClient) error {
+	hits, err := h.Meter.Int64ObservableCounter("redis.client.pool.hits",
+		metric.WithDescription("从连接池中取到空闲连接的次数"),
+		metric.WithUnit("{hit}"))
+	if err != nil {
+		return err
+	}
+	misses, err := h.Meter.Int64ObservableCounter("redis.client.pool.misses",
+		metric.WithDescription("连接池中没有空闲连接的次数"),
+		metric.WithUnit("{miss}"))
+	if err != nil {
+		return err
+	}
+	timeouts, err := h.Meter.Int64ObservableCounter("redis.client.pool.timeouts",
+		metric.WithDescription("等待连接超时的次数"),
+		metric.WithUnit("{timeout}"))
+	if err != nil {
+		return err
+	}
+	total, err := h.Meter.Int64ObservableGauge("redis.client.connections.total",
+		metric.WithDescription("连接池中的连接总数"),
+		metric.WithUnit("{connection}"))
+	if err != nil {
+		return err
+	}
+	idle, err := h.Meter.Int64ObservableGauge("redis.client.connections.idle",
+		metric.WithDescription("连接池中空闲的连接数"),
+		metric.WithUnit("{connection}"))
+	if err != nil {
+		return err
+	}
+	stale, err := h.Meter.Int64ObservableCounter("redis.client.connections.stale",
+		metric.WithDescription("从连接池中移除的过期连接总数"),
+		metric.WithUnit("{connection}"))
+	if err != nil {
+		return err
+	}
+
+	attrs := metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("db.system", "redis"),
+		attribute.String("db.client.connection.pool.name", h.poolName(rdb)),
+	))
+	_, err = h.Meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		stats := rdb.PoolStats()
+		o.ObserveInt64(hits, int64(stats.Hits), attrs)
+		o.ObserveInt64(misses, int64(stats.Misses), attrs)
+		o.ObserveInt64(timeouts, int64(stats.Timeouts), attrs)
+		o.ObserveInt64(total, int64(stats.TotalConns), attrs)
+		o.ObserveInt64(idle, int64(stats.IdleConns), attrs)
+		o.ObserveInt64(stale, int64(stats.StaleConns), attrs)
+		return nil
+	}, hits, misses, timeouts, total, idle, stale)
+	return err
+}
+
+// poolName 返回连接池指标的名称，未配置 PoolName 时使用客户端配置的地址
+func (h *RedisHook) poolName(rdb redis.UniversalClient) string {
+	if h.PoolName != "" {
+		return h.PoolName
+	}
+	switch c := rdb.(type) {
+	case *redis.Client:
+		return c.Options().Addr
+	case *redis.ClusterClient:
+		return strings.Join(c.Options().Addrs, ",")
+	case *redis.Ring:
+		addrs := make([]string, 0, len(c.Options().Addrs))
+		for _, addr := range c.Options().Addrs {
+			addrs = append(addrs, addr)
+		}
+		sort.Strings(addrs)
+		return strings.Join(addrs, ",")
+	}
+	return ""
+}
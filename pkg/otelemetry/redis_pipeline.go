@@ -0,0 +1,77 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisPipelineMode 定义管道中每条命令的记录方式
+type RedisPipelineMode int
+
+const (
+	// RedisPipelineNone 只记录管道整体，不单独记录命令（默认）
+	RedisPipelineNone RedisPipelineMode = iota
+	// RedisPipelineEvents 每条命令在管道 span 上记录一个 redis.command 事件
+	RedisPipelineEvents
+	// RedisPipelineSpans 每条命令创建一个管道 span 的子 span
+	RedisPipelineSpans
+)
+
+// recordPipelineCommands 按 PipelineCommands 记录管道中的每条命令
+// 命令的名称、key 和各自的错误都会被记录，db.redis.pipeline.index 为命令在管道中的位置
+func (h *RedisHook) recordPipelineCommands(ctx context.Context, span trace.Span, cmds []redis.Cmder, start time.Time) {
+	if h.PipelineCommands == RedisPipelineNone || !span.IsRecording() {
+		return
+	}
+	end := time.Now()
+	for i, cmd := range cmds {
+		attrs := []attribute.KeyValue{
+			attribute.String("db.operation", cmd.Name()),
+			attribute.String("db.statement", h.redisStatement(cmd)),
+			attribute.Int("db.redis.pipeline.index", i),
+		}
+		if key := redisKey(cmd); key != "" {
+			attrs = append(attrs, attribute.String("db.redis.key", key))
+		}
+
+		if h.PipelineCommands == RedisPipelineEvents {
+			if err := cmd.Err(); err != nil && h.ErrorClassifier.Classify(err) != ErrorClassIgnore {
+				attrs = append(attrs,
+					ErrorTypeKey.String(errorType(err)),
+					attribute.String("exception.message", err.Error()))
+			}
+			span.AddEvent("redis.command", trace.WithAttributes(attrs...), trace.WithTimestamp(end))
+			continue
+		}
+
+		_, child := h.tracer().Start(ctx, "redis."+cmd.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithTimestamp(start),
+			trace.WithAttributes(attribute.String("db.system", "redis")),
+			trace.WithAttributes(attrs...))
+		recordError(child, cmd.Err(), h.ErrorClassifier)
+		child.End(trace.WithTimestamp(end))
+	}
+}
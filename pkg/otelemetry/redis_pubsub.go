@@ -0,0 +1,194 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redisPropagator Redis 消息传播追踪上下文使用的传播器，固定使用 W3C traceparent 和 baggage，
+// 不依赖全局传播器的配置，保证不同服务之间的格式一致
+var redisPropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// redisEnvelope Pub/Sub 消息信封，将追踪上下文和原始消息一起发布
+type redisEnvelope struct {
+	TraceContext map[string]string `json:"trace_context"` // traceparent、tracestate、baggage
+	Payload      string            `json:"payload"`
+}
+
+// WrapRedisMessage 将消息包装为携带当前追踪上下文的 JSON 信封
+// 信封以 JSON 编码，二进制消息需要先自行编码（如 base64）
+func WrapRedisMessage(ctx context.Context, payload string) (string, error) {
+	carrier := propagation.MapCarrier{}
+	redisPropagator.Inject(ctx, carrier)
+	data, err := json.Marshal(redisEnvelope{TraceContext: carrier, Payload: payload})
+	if err != nil {
+		return "", fmt.Errorf("wrap redis message failed: %w", err)
+	}
+	return string(data), nil
+}
+
+// UnwrapRedisMessage 解析 WrapRedisMessage 生成的信封，返回生产者的追踪上下文和原始消息
+// 消息不是信封时原样返回，返回的上下文中没有远程 span
+func UnwrapRedisMessage(ctx context.Context, message string) (context.Context, string) {
+	var env redisEnvelope
+	if err := json.Unmarshal([]byte(message), &env); err != nil || env.TraceContext == nil {
+		return ctx, message
+	}
+	return redisPropagator.Extract(ctx, propagation.MapCarrier(env.TraceContext)), env.Payload
+}
+
+// Publish 在 publish 生产者 span 中发布消息，消息会被包装为携带追踪上下文的信封
+// 订阅端使用 Channel 或 UnwrapRedisMessage 取出原始消息
+func (h *RedisHook) Publish(ctx context.Context, rdb redis.UniversalClient, channel, payload string) error {
+	ctx, span := h.startPublishSpan(ctx, channel, attribute.Int("messaging.message.body.size", len(payload)))
+	defer span.End()
+
+	message, err := WrapRedisMessage(ctx, payload)
+	if err != nil {
+		recordError(span, err, h.ErrorClassifier)
+		return err
+	}
+	if err = rdb.Publish(ctx, channel, message).Err(); err != nil {
+		recordError(span, err, h.ErrorClassifier)
+		return err
+	}
+	return nil
+}
+
+// RedisMessage 订阅端收到的消息
+// Payload 为解包后的原始消息，Context 中带有 process 消费 span 和合并了生产者 baggage 的 baggage
+type RedisMessage struct {
+	*redis.Message
+	Context context.Context
+
+	span      trace.Span
+	hook      *RedisHook
+	delivered chan struct{} // 交给调用方后关闭，保证 messaging.handoff 事件先于 End 记录
+}
+
+// End 结束消费 span，err 不为 nil 时按 ErrorClassifier 记录到 span 上
+func (m *RedisMessage) End(err error) {
+	<-m.delivered
+	recordError(m.span, err, m.hook.ErrorClassifier)
+	m.span.End()
+}
+
+// Channel 包装 redis.PubSub 的消息通道，为每条消息创建 process 消费 span
+// 消费 span 以 ctx 为父 span，并通过 span link 关联到生产者的 span；处理完消息后需调用 End
+// 消费 span 从收到消息时开始，消息被调用方取走时记录 messaging.handoff 事件，事件之前的时间为排队等待时间
+// ps 关闭或 ctx 结束后返回的通道随之关闭
+func (h *RedisHook) Channel(ctx context.Context, ps *redis.PubSub, opts ...redis.ChannelOption) <-chan *RedisMessage {
+	in := ps.Channel(opts...)
+	out := make(chan *RedisMessage)
+	go func() {
+		defer close(out)
+		for {
+			var msg *redis.Message
+			select {
+			case m, ok := <-in:
+				if !ok {
+					return
+				}
+				msg = m
+			case <-ctx.Done():
+				return
+			}
+
+			m := h.consume(ctx, msg)
+			received := time.Now()
+			select {
+			case out <- m:
+				m.span.AddEvent("messaging.handoff", trace.WithAttributes(
+					attribute.Float64("messaging.redis.queue_wait_ms", float64(time.Since(received))/float64(time.Millisecond)),
+				))
+				close(m.delivered)
+			case <-ctx.Done():
+				close(m.delivered)
+				m.End(ctx.Err())
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// consume 解包消息并创建消费 span
+func (h *RedisHook) consume(ctx context.Context, msg *redis.Message) *RedisMessage {
+	remote, payload := UnwrapRedisMessage(context.Background(), msg.Payload)
+
+	attrs := []attribute.KeyValue{attribute.Int("messaging.message.body.size", len(payload))}
+	if msg.Pattern != "" {
+		attrs = append(attrs, attribute.String("messaging.destination.template", msg.Pattern))
+	}
+	ctx, span := h.startProcessSpan(ctx, remote, msg.Channel, attrs...)
+
+	unwrapped := *msg
+	unwrapped.Payload = payload
+	return &RedisMessage{Message: &unwrapped, Context: ctx, span: span, hook: h, delivered: make(chan struct{})}
+}
+
+// startPublishSpan 创建 publish 生产者 span，Pub/Sub 和 Stream 共用
+func (h *RedisHook) startPublishSpan(ctx context.Context, destination string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return h.tracer().Start(ctx, "publish "+destination,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "redis"),
+			attribute.String("messaging.operation", "publish"),
+			attribute.String("messaging.destination.name", destination),
+		),
+		trace.WithAttributes(attrs...))
+}
+
+// startProcessSpan 创建 process 消费 span，Pub/Sub 和 Stream 共用
+// 消费 span 以 ctx 为父 span，通过 span link 关联到 remote 中的生产者 span
+// 生产者传递的 baggage 合并到 ctx 的 baggage 中，同名的成员以生产者为准
+func (h *RedisHook) startProcessSpan(ctx, remote context.Context, destination string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "redis"),
+			attribute.String("messaging.operation", "process"),
+			attribute.String("messaging.destination.name", destination),
+		),
+		trace.WithAttributes(attrs...),
+	}
+	if sc := trace.SpanContextFromContext(remote); sc.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: sc}))
+	}
+	if producer := baggage.FromContext(remote); producer.Len() > 0 {
+		bag := baggage.FromContext(ctx)
+		for _, m := range producer.Members() {
+			if merged, err := bag.SetMember(m); err == nil {
+				bag = merged
+			}
+		}
+		ctx = baggage.ContextWithBaggage(ctx, bag)
+	}
+	return h.tracer().Start(ctx, "process "+destination, opts...)
+}
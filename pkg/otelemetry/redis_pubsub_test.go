@@ -0,0 +1,230 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// subscribe 订阅频道，并等待服务端确认订阅
+func subscribe(t *testing.T, rdb *redis.Client, channel string) *redis.PubSub {
+	t.Helper()
+	ps := rdb.Subscribe(context.Background(), channel)
+	t.Cleanup(func() { _ = ps.Close() })
+	if _, err := ps.Receive(context.Background()); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	return ps
+}
+
+func newTestBaggage(t *testing.T, kv ...string) baggage.Baggage {
+	t.Helper()
+	var members []baggage.Member
+	for i := 0; i < len(kv); i += 2 {
+		m, err := baggage.NewMember(kv[i], kv[i+1])
+		if err != nil {
+			t.Fatalf("baggage member: %v", err)
+		}
+		members = append(members, m)
+	}
+	bag, err := baggage.New(members...)
+	if err != nil {
+		t.Fatalf("baggage: %v", err)
+	}
+	return bag
+}
+
+func spanEvent(s sdktrace.ReadOnlySpan, name string) (sdktrace.Event, bool) {
+	for _, e := range s.Events() {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return sdktrace.Event{}, false
+}
+
+func TestRedisPubSubHandoff(t *testing.T) {
+	hook := &RedisHook{}
+	rdb, sr, tracer := newTestRedisClient(t, hook)
+	ps := subscribe(t, rdb, "jobs")
+
+	ctx := baggage.ContextWithBaggage(context.Background(), newTestBaggage(t, "tenant", "consumer", "region", "eu"))
+	ctx, parent := tracer.Start(ctx, "consumer")
+	defer parent.End()
+	ch := hook.Channel(ctx, ps)
+
+	pctx := baggage.ContextWithBaggage(context.Background(), newTestBaggage(t, "tenant", "producer", "user", "alice"))
+	if err := hook.Publish(pctx, rdb, "jobs", "hello"); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	var msg *RedisMessage
+	select {
+	case msg = <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the message")
+	}
+	if msg.Payload != "hello" || msg.Channel != "jobs" {
+		t.Errorf("message = %q on %q, want hello on jobs", msg.Payload, msg.Channel)
+	}
+
+	// 生产者的 baggage 合并到消费端的 baggage 中，同名成员以生产者为准
+	bag := baggage.FromContext(msg.Context)
+	want := map[string]string{"tenant": "producer", "region": "eu", "user": "alice"}
+	if bag.Len() != len(want) {
+		t.Errorf("baggage = %s, want %v", bag, want)
+	}
+	for k, v := range want {
+		if got := bag.Member(k).Value(); got != v {
+			t.Errorf("baggage %s = %q, want %q", k, got, v)
+		}
+	}
+	msg.End(nil)
+
+	publish := endedSpans(sr, "publish jobs")
+	process := endedSpans(sr, "process jobs")
+	if len(publish) != 1 || len(process) != 1 {
+		t.Fatalf("got %d publish and %d process spans, want 1 each", len(publish), len(process))
+	}
+	s := process[0]
+	if s.SpanKind() != trace.SpanKindConsumer {
+		t.Errorf("kind = %v, want consumer", s.SpanKind())
+	}
+	if s.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("process span is not a child of the consumer ctx span")
+	}
+	if links := s.Links(); len(links) != 1 || links[0].SpanContext.SpanID() != publish[0].SpanContext().SpanID() {
+		t.Errorf("links = %+v, want the publish span", links)
+	}
+	if v, _ := spanAttr(s, "messaging.message.body.size"); v != "5" {
+		t.Errorf("messaging.message.body.size = %q, want 5", v)
+	}
+	handoff, ok := spanEvent(s, "messaging.handoff")
+	if !ok {
+		t.Fatal("missing messaging.handoff event")
+	}
+	var wait float64 = -1
+	for _, kv := range handoff.Attributes {
+		if kv.Key == "messaging.redis.queue_wait_ms" {
+			wait = kv.Value.AsFloat64()
+		}
+	}
+	if wait < 0 {
+		t.Errorf("messaging.handoff attributes = %v, want a queue wait", handoff.Attributes)
+	}
+	if s.Status().Code == codes.Error {
+		t.Errorf("status = %v, want unset", s.Status())
+	}
+}
+
+func TestRedisPubSubChannelCancel(t *testing.T) {
+	t.Run("pending message", func(t *testing.T) {
+		hook := &RedisHook{}
+		rdb, sr, _ := newTestRedisClient(t, hook)
+		ps := subscribe(t, rdb, "jobs")
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		ch := hook.Channel(ctx, ps)
+
+		if err := hook.Publish(context.Background(), rdb, "jobs", "hello"); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+		// 消息已经创建了消费 span，但还没有被调用方取走
+		waitFor(t, "process span to start", func() bool {
+			for _, s := range sr.Started() {
+				if s.Name() == "process jobs" {
+					return true
+				}
+			}
+			return false
+		})
+		cancel()
+
+		// 等待中的 span 以 ctx.Err() 结束后通道关闭
+		waitFor(t, "process span to end", func() bool { return len(endedSpans(sr, "process jobs")) == 1 })
+		select {
+		case m, ok := <-ch:
+			if ok {
+				t.Fatalf("received %q after cancel, want a closed channel", m.Payload)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("channel not closed after cancel")
+		}
+
+		s := endedSpans(sr, "process jobs")[0]
+		if v, _ := spanAttr(s, "error.type"); v != "canceled" {
+			t.Errorf("error.type = %q, want canceled", v)
+		}
+		exception, ok := spanEvent(s, "exception")
+		if !ok {
+			t.Fatal("missing exception event")
+		}
+		for _, kv := range exception.Attributes {
+			if kv.Key == "exception.message" && kv.Value.AsString() != context.Canceled.Error() {
+				t.Errorf("exception.message = %q, want %q", kv.Value.AsString(), context.Canceled)
+			}
+		}
+		if _, ok := spanEvent(s, "messaging.handoff"); ok {
+			t.Error("message that was never delivered recorded messaging.handoff")
+		}
+	})
+
+	t.Run("idle", func(t *testing.T) {
+		hook := &RedisHook{}
+		rdb, sr, _ := newTestRedisClient(t, hook)
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := hook.Channel(ctx, subscribe(t, rdb, "jobs"))
+		cancel()
+		select {
+		case _, ok := <-ch:
+			if ok {
+				t.Fatal("received a message after cancel")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("channel not closed after cancel")
+		}
+		if spans := endedSpans(sr, "process jobs"); len(spans) != 0 {
+			t.Errorf("created %d process spans without messages", len(spans))
+		}
+	})
+
+	t.Run("pubsub closed", func(t *testing.T) {
+		hook := &RedisHook{}
+		rdb, _, _ := newTestRedisClient(t, hook)
+		ps := subscribe(t, rdb, "jobs")
+		ch := hook.Channel(context.Background(), ps)
+		_ = ps.Close()
+		select {
+		case _, ok := <-ch:
+			if ok {
+				t.Fatal("received a message after close")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("channel not closed after the pubsub was closed")
+		}
+	})
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStatementMode 定义 Redis 命令 db.statement 的记录方式
+type RedisStatementMode int
+
+const (
+	// RedisStatementKeyOnly 只记录命令名和 key，不包含任何值（默认）
+	RedisStatementKeyOnly RedisStatementMode = iota
+	// RedisStatementTruncated 记录命令名、key 和参数，参数个数和长度受 MaxArgs、MaxArgLength 限制
+	RedisStatementTruncated
+	// RedisStatementFull 记录完整的参数，可能包含敏感数据和大体积的值
+	RedisStatementFull
+)
+
+const (
+	defaultRedisMaxArgs      = 10 // 截断模式下默认最多记录的参数个数
+	defaultRedisMaxArgLength = 64 // 截断模式下默认每个参数最多记录的字节数
+)
+
+// redisMaskedCommands 参数中可能包含密码的命令，无论哪种模式都只记录命令名，参数替换为 ?
+var redisMaskedCommands = map[string]bool{
+	"auth":    true,
+	"hello":   true,
+	"migrate": true,
+}
+
+// redisKeylessCommands 不带 key 的命令，只记录命令名
+var redisKeylessCommands = map[string]bool{
+	"ping": true, "echo": true, "info": true, "select": true, "quit": true,
+	"client": true, "config": true, "cluster": true, "command": true, "debug": true,
+	"dbsize": true, "flushall": true, "flushdb": true, "time": true, "role": true,
+	"save": true, "bgsave": true, "bgrewriteaof": true, "lastsave": true, "shutdown": true,
+	"multi": true, "exec": true, "discard": true, "unwatch": true,
+	"script": true, "function": true, "slowlog": true, "latency": true, "monitor": true,
+	"subscribe": true, "psubscribe": true, "unsubscribe": true, "punsubscribe": true, "pubsub": true,
+	"readonly": true, "readwrite": true, "swapdb": true, "acl": true, "module": true,
+}
+
+// redisSubcommands 第二个参数是子命令的命令
+var redisSubcommands = map[string]bool{
+	"client": true, "config": true, "cluster": true, "command": true, "debug": true,
+	"script": true, "function": true, "slowlog": true, "latency": true, "pubsub": true,
+	"acl": true, "module": true, "memory": true, "object": true, "xinfo": true, "xgroup": true,
+}
+
+// redisStatement 按模式生成命令的 db.statement
+func (h *RedisHook) redisStatement(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) == 0 {
+		return ""
+	}
+	name := cmd.Name()
+
+	var b strings.Builder
+	b.WriteString(redisArgString(args[0]))
+
+	if redisMaskedCommands[name] {
+		for range args[1:] {
+			b.WriteString(" ?")
+		}
+		return b.String()
+	}
+
+	switch h.StatementMode {
+	case RedisStatementFull:
+		for _, arg := range args[1:] {
+			b.WriteByte(' ')
+			b.WriteString(redisArgString(arg))
+		}
+	case RedisStatementTruncated:
+		maxArgs, maxLen := h.MaxArgs, h.MaxArgLength
+		if maxArgs <= 0 {
+			maxArgs = defaultRedisMaxArgs
+		}
+		if maxLen <= 0 {
+			maxLen = defaultRedisMaxArgLength
+		}
+		for i, arg := range args[1:] {
+			if i >= maxArgs {
+				b.WriteString(" ... (" + strconv.Itoa(len(args)-1-i) + " more)")
+				break
+			}
+			b.WriteByte(' ')
+			b.WriteString(truncateString(redisArgString(arg), maxLen))
+		}
+	default:
+		// 带子命令的命令（如 CLIENT SETNAME、XGROUP CREATE）同时记录子命令
+		if redisSubcommands[name] && len(args) > 1 {
+			b.WriteByte(' ')
+			b.WriteString(redisArgString(args[1]))
+		}
+		if pos := redisKeyPos(cmd); pos > 0 && pos < len(args) {
+			b.WriteByte(' ')
+			b.WriteString(redisArgString(args[pos]))
+		}
+	}
+	return b.String()
+}
+
+// redisKey 返回命令中的第一个 key，没有 key 时返回空字符串
+func redisKey(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if pos := redisKeyPos(cmd); pos > 0 && pos < len(args) {
+		return redisArgString(args[pos])
+	}
+	return ""
+}
+
+// redisKeyPos 返回命令中第一个 key 的位置，没有 key 时返回 0
+func redisKeyPos(cmd redis.Cmder) int {
+	name := cmd.Name()
+	if redisKeylessCommands[name] || redisMaskedCommands[name] {
+		return 0
+	}
+	args := cmd.Args()
+	switch name {
+	case "eval", "evalsha", "eval_ro", "evalsha_ro", "fcall", "fcall_ro":
+		// EVAL script numkeys key [key ...]，numkeys 为 0 时没有 key
+		if len(args) > 2 && redisArgString(args[2]) != "0" {
+			return 3
+		}
+		return 0
+	case "memory":
+		if len(args) > 1 && strings.EqualFold(redisArgString(args[1]), "usage") {
+			return 2
+		}
+		return 0
+	case "object", "xinfo", "xgroup":
+		// OBJECT ENCODING key、XINFO STREAM key、XGROUP CREATE key group id
+		return 2
+	case "xread", "xreadgroup":
+		// XREAD ... STREAMS key [key ...] id [id ...]
+		for i, arg := range args {
+			if strings.EqualFold(redisArgString(arg), "streams") {
+				return i + 1
+			}
+		}
+		return 0
+	}
+	return 1
+}
+
+// redisArgString 将命令参数转换为字符串
+func redisArgString(arg interface{}) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// truncateString 将字符串截断到最多 max 字节，不截断多字节字符，截断后追加 ...
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	end := max
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + "..."
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisStatement(t *testing.T) {
+	keyOnly := &RedisHook{}
+	truncated := &RedisHook{StatementMode: RedisStatementTruncated, MaxArgs: 2, MaxArgLength: 4}
+	defaults := &RedisHook{StatementMode: RedisStatementTruncated}
+	full := &RedisHook{StatementMode: RedisStatementFull}
+
+	manyArgs := []interface{}{"rpush", "list"}
+	for i := 0; i < 12; i++ {
+		manyArgs = append(manyArgs, i)
+	}
+
+	tests := []struct {
+		name string
+		hook *RedisHook
+		args []interface{}
+		want string
+	}{
+		// 默认只记录命令名、子命令和第一个 key
+		{"key only get", keyOnly, []interface{}{"get", "user:1"}, "get user:1"},
+		{"key only set", keyOnly, []interface{}{"set", "user:1", "secret", "ex", 10}, "set user:1"},
+		{"key only keyless", keyOnly, []interface{}{"ping"}, "ping"},
+		{"key only subcommand", keyOnly, []interface{}{"client", "setname", "api"}, "client setname"},
+		{"key only subcommand with key", keyOnly, []interface{}{"xgroup", "create", "orders", "workers", "$"}, "xgroup create orders"},
+		{"key only memory usage", keyOnly, []interface{}{"memory", "usage", "user:1"}, "memory usage user:1"},
+		{"key only eval", keyOnly, []interface{}{"eval", "return redis.call('get', KEYS[1])", 1, "user:1", "arg"}, "eval user:1"},
+		{"key only eval without keys", keyOnly, []interface{}{"eval", "return 1", 0}, "eval"},
+		{"key only xreadgroup", keyOnly, []interface{}{"xreadgroup", "group", "g", "c", "count", 10, "streams", "orders", ">"}, "xreadgroup orders"},
+		{"key only bytes key", keyOnly, []interface{}{"get", []byte("user:2")}, "get user:2"},
+
+		// 截断模式限制参数个数和每个参数的长度
+		{"truncated", truncated, []interface{}{"set", "k1", "abcdefgh", "ex", 10}, "set k1 abcd... ... (2 more)"},
+		{"truncated within limits", truncated, []interface{}{"get", "k"}, "get k"},
+		{"truncated utf8", truncated, []interface{}{"set", "k", "日本"}, "set k 日..."},
+		{"truncated defaults", defaults, manyArgs, "rpush list 0 1 2 3 4 5 6 7 8 ... (3 more)"},
+		{"truncated default length", defaults, []interface{}{"set", "k", strings.Repeat("a", 70)}, "set k " + strings.Repeat("a", 64) + "..."},
+
+		// 完整模式记录全部参数
+		{"full", full, []interface{}{"set", "user:1", "bob", "ex", 10}, "set user:1 bob ex 10"},
+
+		// 可能包含密码的命令在任何模式下都替换参数
+		{"auth key only", keyOnly, []interface{}{"auth", "default", "p@ss"}, "auth ? ?"},
+		{"auth full", full, []interface{}{"auth", "p@ss"}, "auth ?"},
+		{"hello truncated", truncated, []interface{}{"hello", 3, "auth", "default", "p@ss"}, "hello ? ? ? ?"},
+		{"migrate full", full, []interface{}{"migrate", "10.0.0.2", 6379, "user:1", 0, 5000, "auth", "p@ss"}, "migrate ? ? ? ? ? ? ?"},
+
+		{"empty", full, nil, ""},
+	}
+	for _, tt := range tests {
+		cmd := redis.NewCmd(context.Background(), tt.args...)
+		if got := tt.hook.redisStatement(cmd); got != tt.want {
+			t.Errorf("%s: redisStatement(%v) = %q, want %q", tt.name, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{"abc", 3, "abc"},
+		{"abcd", 3, "abc..."},
+		{"héllo", 2, "h..."},
+		{"héllo", 3, "hé..."},
+		{"日本語", 4, "日..."},
+		{"日本語", 6, "日本..."},
+		{"日本語", 2, "..."},
+		{"", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := truncateString(tt.s, tt.max); got != tt.want {
+			t.Errorf("truncateString(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redisTraceFields Stream 消息中保存追踪上下文的字段
+var redisTraceFields = []string{"traceparent", "tracestate", "baggage"}
+
+// RedisStreamMessage 从 Stream 中读取的消息
+// Values 中已移除追踪上下文字段，Context 中带有 process 消费 span 和合并了生产者 baggage 的 baggage
+type RedisStreamMessage struct {
+	redis.XMessage
+	Stream  string
+	Context context.Context
+
+	span trace.Span
+	hook *RedisHook
+}
+
+// End 结束消费 span，err 不为 nil 时按 ErrorClassifier 记录到 span 上
+func (m *RedisStreamMessage) End(err error) {
+	recordError(m.span, err, m.hook.ErrorClassifier)
+	m.span.End()
+}
+
+// XAdd 在 publish 生产者 span 中执行 XADD，并将追踪上下文作为 traceparent、tracestate、baggage 字段写入消息
+// a.Values 支持 map[string]interface{}、map[string]string、[]interface{} 和 []string，不会修改调用方的参数
+func (h *RedisHook) XAdd(ctx context.Context, rdb redis.UniversalClient, a *redis.XAddArgs) (string, error) {
+	ctx, span := h.startPublishSpan(ctx, a.Stream)
+	defer span.End()
+
+	carrier := propagation.MapCarrier{}
+	redisPropagator.Inject(ctx, carrier)
+	values, err := redisStreamValues(a.Values, carrier)
+	if err != nil {
+		recordError(span, err, h.ErrorClassifier)
+		return "", err
+	}
+	args := *a
+	args.Values = values
+
+	id, err := rdb.XAdd(ctx, &args).Result()
+	if err != nil {
+		recordError(span, err, h.ErrorClassifier)
+		return "", err
+	}
+	span.SetAttributes(attribute.String("messaging.message.id", id))
+	return id, nil
+}
+
+// redisStreamValues 将消息字段和追踪上下文合并为 XADD 的参数
+func redisStreamValues(values interface{}, carrier propagation.MapCarrier) ([]interface{}, error) {
+	var merged []interface{}
+	switch v := values.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			merged = append(merged, k, val)
+		}
+	case map[string]string:
+		for k, val := range v {
+			merged = append(merged, k, val)
+		}
+	case []interface{}:
+		merged = append(merged, v...)
+	case []string:
+		for _, val := range v {
+			merged = append(merged, val)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported redis stream values type %T", values)
+	}
+	for _, k := range redisTraceFields {
+		if val := carrier.Get(k); val != "" {
+			merged = append(merged, k, val)
+		}
+	}
+	return merged, nil
+}
+
+// XReadGroup 执行 XREADGROUP，并为读取到的每条消息创建 process 消费 span
+// 读取新消息（ID 为 >）时投递次数为 1；重新读取待确认消息时通过 XPENDING 查询实际的投递次数
+// 没有消息时返回 redis.Nil，处理完每条消息后需调用 End
+func (h *RedisHook) XReadGroup(ctx context.Context, rdb redis.UniversalClient, a *redis.XReadGroupArgs) ([]*RedisStreamMessage, error) {
+	streams, err := rdb.XReadGroup(ctx, a).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// Streams 的前一半是 Stream 名称，后一半是对应的起始 ID
+	ids := make(map[string]string, len(a.Streams)/2)
+	for i := 0; i < len(a.Streams)/2; i++ {
+		ids[a.Streams[i]] = a.Streams[i+len(a.Streams)/2]
+	}
+
+	var messages []*RedisStreamMessage
+	for _, stream := range streams {
+		var deliveries map[string]int64
+		if ids[stream.Stream] != ">" && len(stream.Messages) > 0 {
+			deliveries = h.deliveryCounts(ctx, rdb, a, stream)
+		}
+		for _, msg := range stream.Messages {
+			count := int64(1)
+			if deliveries != nil {
+				count = deliveries[msg.ID]
+			}
+			messages = append(messages, h.ProcessStreamMessage(ctx, stream.Stream, a.Group, a.Consumer, count, msg))
+		}
+	}
+	return messages, nil
+}
+
+// deliveryCounts 通过 XPENDING 查询待确认消息的投递次数，查询失败时返回空
+func (h *RedisHook) deliveryCounts(ctx context.Context, rdb redis.UniversalClient, a *redis.XReadGroupArgs, stream redis.XStream) map[string]int64 {
+	pending, err := rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   stream.Stream,
+		Group:    a.Group,
+		Start:    stream.Messages[0].ID,
+		End:      stream.Messages[len(stream.Messages)-1].ID,
+		Count:    int64(len(stream.Messages)),
+		Consumer: a.Consumer,
+	}).Result()
+	if err != nil {
+		return map[string]int64{}
+	}
+	counts := make(map[string]int64, len(pending))
+	for _, p := range pending {
+		counts[p.ID] = p.RetryCount
+	}
+	return counts
+}
+
+// ProcessStreamMessage 为一条 Stream 消息创建 process 消费 span，并从消息字段中提取生产者的追踪上下文
+// 适用于 XAUTOCLAIM、XCLAIM 等自行读取消息的场景，deliveryCount <= 0 时不记录投递次数
+func (h *RedisHook) ProcessStreamMessage(ctx context.Context, stream, group, consumer string, deliveryCount int64, msg redis.XMessage) *RedisStreamMessage {
+	carrier := propagation.MapCarrier{}
+	values := make(map[string]interface{}, len(msg.Values))
+	for k, v := range msg.Values {
+		if s, ok := v.(string); ok && isRedisTraceField(k) {
+			carrier[k] = s
+			continue
+		}
+		values[k] = v
+	}
+	remote := redisPropagator.Extract(context.Background(), carrier)
+
+	attrs := []attribute.KeyValue{attribute.String("messaging.message.id", msg.ID)}
+	if group != "" {
+		attrs = append(attrs, attribute.String("messaging.consumer.group.name", group))
+	}
+	if consumer != "" {
+		attrs = append(attrs, attribute.String("messaging.redis.consumer.name", consumer))
+	}
+	if deliveryCount > 0 {
+		attrs = append(attrs, attribute.Int64("messaging.redis.delivery_count", deliveryCount))
+	}
+	ctx, span := h.startProcessSpan(ctx, remote, stream, attrs...)
+
+	msg.Values = values
+	return &RedisStreamMessage{XMessage: msg, Stream: stream, Context: ctx, span: span, hook: h}
+}
+
+// isRedisTraceField 判断字段是否为追踪上下文字段
+func isRedisTraceField(field string) bool {
+	for _, f := range redisTraceFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
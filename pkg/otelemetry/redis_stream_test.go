@@ -0,0 +1,172 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceparent 返回 span 对应的 W3C traceparent
+func traceparent(s sdktrace.ReadOnlySpan) string {
+	return fmt.Sprintf("00-%s-%s-01", s.SpanContext().TraceID(), s.SpanContext().SpanID())
+}
+
+func TestRedisStreamXAdd(t *testing.T) {
+	hook := &RedisHook{}
+	rdb, sr, _ := newTestRedisClient(t, hook)
+	ctx := context.Background()
+	if err := rdb.XGroupCreateMkStream(ctx, "orders", "workers", "0").Err(); err != nil {
+		t.Fatalf("xgroup create: %v", err)
+	}
+
+	pctx := baggage.ContextWithBaggage(ctx, newTestBaggage(t, "user", "alice"))
+	mapValues := map[string]interface{}{"order": "42"}
+	for _, values := range []interface{}{
+		mapValues,
+		map[string]string{"order": "42"},
+		[]interface{}{"order", "42"},
+		[]string{"order", "42"},
+	} {
+		if _, err := hook.XAdd(pctx, rdb, &redis.XAddArgs{Stream: "orders", Values: values}); err != nil {
+			t.Fatalf("xadd %T: %v", values, err)
+		}
+	}
+	if !reflect.DeepEqual(mapValues, map[string]interface{}{"order": "42"}) {
+		t.Errorf("XAdd modified the caller's values: %v", mapValues)
+	}
+
+	// 不经过 hook 读取原始消息，追踪上下文作为字段写入
+	streams, err := rdb.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "workers", Consumer: "c1", Streams: []string{"orders", ">"}}).Result()
+	if err != nil {
+		t.Fatalf("xreadgroup: %v", err)
+	}
+	publish := endedSpans(sr, "publish orders")
+	if len(streams) != 1 || len(streams[0].Messages) != len(publish) || len(publish) != 4 {
+		t.Fatalf("read %+v for %d publish spans, want 4 messages", streams, len(publish))
+	}
+	for i, msg := range streams[0].Messages {
+		want := map[string]interface{}{"order": "42", "traceparent": traceparent(publish[i]), "baggage": "user=alice"}
+		if !reflect.DeepEqual(msg.Values, want) {
+			t.Errorf("message %d values = %v, want %v", i, msg.Values, want)
+		}
+		if publish[i].SpanKind() != trace.SpanKindProducer {
+			t.Errorf("publish span kind = %v, want producer", publish[i].SpanKind())
+		}
+		if v, _ := spanAttr(publish[i], "messaging.message.id"); v != msg.ID {
+			t.Errorf("messaging.message.id = %q, want %q", v, msg.ID)
+		}
+	}
+
+	if _, err := hook.XAdd(ctx, rdb, &redis.XAddArgs{Stream: "orders", Values: 42}); err == nil {
+		t.Fatal("xadd with unsupported values succeeded")
+	}
+	if s := endedSpans(sr, "publish orders"); s[len(s)-1].Status().Code != codes.Error {
+		t.Errorf("status = %v, want error for unsupported values", s[len(s)-1].Status())
+	}
+}
+
+func TestRedisStreamXReadGroup(t *testing.T) {
+	hook := &RedisHook{}
+	rdb, sr, tracer := newTestRedisClient(t, hook)
+	if err := rdb.XGroupCreateMkStream(context.Background(), "orders", "workers", "0").Err(); err != nil {
+		t.Fatalf("xgroup create: %v", err)
+	}
+	pctx := baggage.ContextWithBaggage(context.Background(), newTestBaggage(t, "user", "alice"))
+	id, err := hook.XAdd(pctx, rdb, &redis.XAddArgs{Stream: "orders", Values: map[string]interface{}{"order": "42"}})
+	if err != nil {
+		t.Fatalf("xadd: %v", err)
+	}
+	publish := endedSpans(sr, "publish orders")[0]
+
+	ctx, parent := tracer.Start(context.Background(), "worker")
+	defer parent.End()
+	read := func(start string) *RedisStreamMessage {
+		t.Helper()
+		messages, err := hook.XReadGroup(ctx, rdb, &redis.XReadGroupArgs{
+			Group: "workers", Consumer: "c1", Streams: []string{"orders", start}, Count: 10,
+		})
+		if err != nil {
+			t.Fatalf("xreadgroup %s: %v", start, err)
+		}
+		if len(messages) != 1 {
+			t.Fatalf("xreadgroup %s: got %d messages, want 1", start, len(messages))
+		}
+		messages[0].End(nil)
+		return messages[0]
+	}
+
+	// 新消息的投递次数为 1，重新读取待确认消息时通过 XPENDING 查询投递次数
+	for i, tt := range []struct {
+		start string
+		count string
+	}{
+		{">", "1"},
+		{"0", "2"},
+	} {
+		msg := read(tt.start)
+		if msg.ID != id || msg.Stream != "orders" {
+			t.Errorf("%s: message %s on %s, want %s on orders", tt.start, msg.ID, msg.Stream, id)
+		}
+		if !reflect.DeepEqual(msg.Values, map[string]interface{}{"order": "42"}) {
+			t.Errorf("%s: values = %v, want trace fields removed", tt.start, msg.Values)
+		}
+		if got := baggage.FromContext(msg.Context).Member("user").Value(); got != "alice" {
+			t.Errorf("%s: baggage user = %q, want alice", tt.start, got)
+		}
+
+		spans := endedSpans(sr, "process orders")
+		if len(spans) != i+1 {
+			t.Fatalf("%s: got %d process spans, want %d", tt.start, len(spans), i+1)
+		}
+		s := spans[i]
+		if s.SpanKind() != trace.SpanKindConsumer || s.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%s: process span kind %v, parent %v", tt.start, s.SpanKind(), s.Parent().SpanID())
+		}
+		if links := s.Links(); len(links) != 1 || links[0].SpanContext.SpanID() != publish.SpanContext().SpanID() {
+			t.Errorf("%s: links = %+v, want the publish span", tt.start, links)
+		}
+		for key, want := range map[string]string{
+			"messaging.message.id":           id,
+			"messaging.consumer.group.name":  "workers",
+			"messaging.redis.consumer.name":  "c1",
+			"messaging.redis.delivery_count": tt.count,
+		} {
+			if v, _ := spanAttr(s, key); v != want {
+				t.Errorf("%s: %s = %q, want %q", tt.start, key, v, want)
+			}
+		}
+	}
+
+	// 没有新消息时返回 redis.Nil
+	if _, err := hook.XReadGroup(ctx, rdb, &redis.XReadGroupArgs{
+		Group: "workers", Consumer: "c1", Streams: []string{"orders", ">"},
+	}); !errors.Is(err, redis.Nil) {
+		t.Errorf("empty read err = %v, want redis.Nil", err)
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redisFailoverAddr 哨兵客户端（NewFailoverClient）配置中的占位地址，实际地址在建连时才确定
+const redisFailoverAddr = "FailoverClient"
+
+// redisClusterSlots Redis 集群的哈希槽数量
+const redisClusterSlots = 16384
+
+// Instrument 为 Redis 客户端安装追踪钩子，并记录命令所在节点的拓扑信息
+// 普通客户端、哨兵客户端、集群客户端和 Ring 客户端的命令 span 会带上节点地址、DB 编号和客户端名称，
+// 集群客户端还会记录 key 的哈希槽，并为 MOVED/ASK 重定向创建 redis.redirect span
+// 配置了 Meter 时还会注册连接池指标
+// 集群和 Ring 的节点是在执行命令时才创建的，应在创建客户端后、执行命令前调用；Ring 客户端还需要先调用 InstrumentRingOptions
+func (h *RedisHook) Instrument(rdb redis.UniversalClient) {
+	rdb.AddHook(h)
+	if h.Meter != nil {
+		if err := h.registerPoolMetrics(rdb); err != nil {
+			log.Printf("register redis pool metrics failed: %v", err)
+		}
+	}
+	switch c := rdb.(type) {
+	case *redis.Client:
+		c.AddHook(newRedisNodeHook(h, c, false, false))
+	case *redis.ClusterClient:
+		c.OnNewNode(func(node *redis.Client) {
+			node.AddHook(newRedisNodeHook(h, node, true, true))
+		})
+	case *redis.Ring:
+		// 分片钩子已经在创建分片客户端时安装
+		if _, ok := h.ringOptions.Load(c.Options()); ok {
+			break
+		}
+		install := func(shard *redis.Client) {
+			shard.AddHook(newRedisNodeHook(h, shard, false, true))
+		}
+		c.OnNewNode(install)
+		var live atomic.Int64
+		_ = c.ForEachShard(context.Background(), func(_ context.Context, shard *redis.Client) error {
+			install(shard)
+			live.Add(1)
+			return nil
+		})
+		if n := len(c.Options().Addrs); int(live.Load()) < n {
+			log.Printf("redis ring: %d of %d shards are down and their commands will miss node attributes, "+
+				"call RedisHook.InstrumentRingOptions before redis.NewRing", n-int(live.Load()), n)
+		}
+	}
+}
+
+// InstrumentRingOptions 在 redis.NewRing 之前调用，通过 RingOptions.NewClient 在创建分片客户端时安装节点钩子
+// Ring.ForEachShard 会跳过下线的分片，且 go-redis 不支持在已经执行命令的客户端上并发 AddHook，
+// 因此 Ring 客户端应先调用该方法再创建，之后的 Instrument 不再逐个分片安装
+func (h *RedisHook) InstrumentRingOptions(opt *redis.RingOptions) {
+	newClient := opt.NewClient
+	if newClient == nil {
+		newClient = redis.NewClient
+	}
+	opt.NewClient = func(o *redis.Options) *redis.Client {
+		shard := newClient(o)
+		shard.AddHook(newRedisNodeHook(h, shard, false, true))
+		return shard
+	}
+	h.ringOptions.Store(opt, struct{}{})
+}
+
+// redisNodeHook 安装在单个节点客户端上的钩子，为外层 RedisHook 创建的 span 补充节点信息
+type redisNodeHook struct {
+	hook    *RedisHook
+	cluster bool                 // 是否为集群节点，集群节点记录哈希槽和重定向
+	sharded bool                 // 是否为集群或 Ring 的节点，这类客户端的一个管道可能分发到多个节点
+	attrs   []attribute.KeyValue // DB 编号和客户端名称
+	addr    atomic.Value         // 节点地址，哨兵客户端在建连后更新为实际地址
+}
+
+// newRedisNodeHook 根据节点客户端的配置创建节点钩子
+func newRedisNodeHook(h *RedisHook, rdb *redis.Client, cluster, sharded bool) *redisNodeHook {
+	opt := rdb.Options()
+	n := &redisNodeHook{hook: h, cluster: cluster, sharded: sharded}
+	if !cluster {
+		n.attrs = append(n.attrs, attribute.Int("db.redis.database_index", opt.DB))
+	}
+	if opt.ClientName != "" {
+		n.attrs = append(n.attrs, attribute.String("db.redis.client_name", opt.ClientName))
+	}
+	if opt.Addr != redisFailoverAddr {
+		n.addr.Store(opt.Addr)
+	}
+	return n
+}
+
+// attributes 返回节点的属性
+func (n *redisNodeHook) attributes() []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if addr, _ := n.addr.Load().(string); addr != "" {
+		attrs = redisServerAttributes(addr)
+	}
+	return append(attrs, n.attrs...)
+}
+
+// DialHook 实现 redis.Hook 接口
+// 集群和 Ring 的节点连接不经过外层客户端的钩子，由这里创建 redis.dial span；
+// 哨兵客户端在建连后才知道主节点的实际地址，这里记录下来并补充到 redis.dial span 上
+func (n *redisNodeHook) DialHook(next redis.DialHook) redis.DialHook {
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err == nil && addr == redisFailoverAddr {
+			remote := conn.RemoteAddr().String()
+			n.addr.Store(remote)
+			trace.SpanFromContext(ctx).SetAttributes(redisServerAttributes(remote)...)
+		}
+		return conn, err
+	}
+	if n.sharded {
+		return n.hook.DialHook(dial)
+	}
+	return dial
+}
+
+// ProcessHook 实现 redis.Hook 接口，为命令 span 补充节点信息
+// 集群重定向后命令会在新节点上重新执行，span 上最终记录的是实际执行命令的节点
+func (n *redisNodeHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		span, ok := ctx.Value(redisSpanKey).(trace.Span)
+		if !ok {
+			return next(ctx, cmd)
+		}
+		span.SetAttributes(n.attributes()...)
+		if n.cluster {
+			if key := redisKey(cmd); key != "" {
+				span.SetAttributes(attribute.Int("db.redis.hash_slot", redisHashSlot(key)))
+			}
+		}
+
+		start := time.Now()
+		err := next(ctx, cmd)
+		n.recordRedirect(ctx, err, start)
+		return err
+	}
+}
+
+// ProcessPipelineHook 实现 redis.Hook 接口，为管道 span 补充节点信息
+// 集群和 Ring 的管道按节点拆分执行，每个节点在管道 span 上记录一个 redis.node 事件
+func (n *redisNodeHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		span, ok := ctx.Value(redisPipelineSpanKey).(trace.Span)
+		if !ok {
+			return next(ctx, cmds)
+		}
+		if n.sharded {
+			span.AddEvent("redis.node", trace.WithAttributes(n.attributes()...),
+				trace.WithAttributes(attribute.Int("db.redis.num_cmd", len(cmds))))
+		} else {
+			span.SetAttributes(n.attributes()...)
+		}
+
+		start := time.Now()
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			n.recordRedirect(ctx, cmd.Err(), start)
+		}
+		return err
+	}
+}
+
+// recordRedirect 命令返回 MOVED/ASK 错误时创建 redis.redirect span，记录返回重定向的节点和目标节点
+func (n *redisNodeHook) recordRedirect(ctx context.Context, err error, start time.Time) {
+	if !n.cluster || err == nil {
+		return
+	}
+	var kind string
+	switch {
+	case redis.HasErrorPrefix(err, "MOVED "):
+		kind = "moved"
+	case redis.HasErrorPrefix(err, "ASK "):
+		kind = "ask"
+	default:
+		return
+	}
+
+	attrs := append(n.attributes(), attribute.String("db.system", "redis"), attribute.String("db.redis.redirect", kind))
+	// 错误格式为 MOVED <slot> <addr>
+	if fields := strings.Fields(err.Error()); len(fields) == 3 {
+		if slot, convErr := strconv.Atoi(fields[1]); convErr == nil {
+			attrs = append(attrs, attribute.Int("db.redis.hash_slot", slot))
+		}
+		attrs = append(attrs, attribute.String("db.redis.redirect.address", fields[2]))
+	}
+	_, span := n.hook.tracer().Start(ctx, "redis.redirect",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(attrs...))
+	span.End()
+}
+
+// redisHashSlot 计算 key 所在的集群哈希槽，key 中有 {hashtag} 时只计算 hashtag 部分
+func redisHashSlot(key string) int {
+	if s := strings.IndexByte(key, '{'); s >= 0 {
+		if e := strings.IndexByte(key[s+1:], '}'); e > 0 {
+			key = key[s+1 : s+1+e]
+		}
+	}
+	return int(crc16(key)) % redisClusterSlots
+}
+
+// crc16 Redis 集群使用的 CRC16（XMODEM）校验
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
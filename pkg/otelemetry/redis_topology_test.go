@@ -0,0 +1,193 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestCRC16(t *testing.T) {
+	// CRC16/XMODEM 的标准校验值
+	if got := crc16("123456789"); got != 0x31c3 {
+		t.Fatalf("crc16(123456789) = %#x, want 0x31c3", got)
+	}
+	if got := crc16(""); got != 0 {
+		t.Fatalf("crc16(\"\") = %#x, want 0", got)
+	}
+}
+
+func TestRedisHashSlot(t *testing.T) {
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"foo", 12182},
+		{"bar", 5061},
+		{"", 0},
+		// 只计算第一个 {} 中的内容
+		{"{user1000}.following", redisHashSlot("user1000")},
+		{"{user1000}.followers", redisHashSlot("user1000")},
+		{"foo{bar}{zap}", redisHashSlot("bar")},
+		{"foo{{bar}}zap", redisHashSlot("{bar")},
+		// {} 为空或不成对时计算整个 key
+		{"foo{}{bar}", int(crc16("foo{}{bar}")) % redisClusterSlots},
+		{"foo{bar", int(crc16("foo{bar")) % redisClusterSlots},
+	}
+	for _, tt := range tests {
+		if got := redisHashSlot(tt.key); got != tt.want {
+			t.Errorf("redisHashSlot(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+}
+
+// testRedisError 实现 redis.Error 接口的服务端错误
+type testRedisError string
+
+func (e testRedisError) Error() string { return string(e) }
+func (testRedisError) RedisError()     {}
+
+func TestRedisRecordRedirect(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster bool
+		err     error
+		want    map[string]string // 为 nil 表示不创建 span
+	}{
+		{"moved", true, testRedisError("MOVED 3999 127.0.0.1:6381"), map[string]string{
+			"db.redis.redirect": "moved", "db.redis.hash_slot": "3999", "db.redis.redirect.address": "127.0.0.1:6381",
+			"server.address": "127.0.0.1", "server.port": "7000",
+		}},
+		{"ask", true, testRedisError("ASK 3999 127.0.0.1:6382"), map[string]string{
+			"db.redis.redirect": "ask", "db.redis.hash_slot": "3999", "db.redis.redirect.address": "127.0.0.1:6382",
+		}},
+		{"other redis error", true, testRedisError("ERR wrong number of arguments"), nil},
+		{"network error", true, errors.New("MOVED 1 127.0.0.1:1"), nil},
+		{"not a cluster node", false, testRedisError("MOVED 3999 127.0.0.1:6381"), nil},
+		{"no error", true, nil, nil},
+	}
+	for _, tt := range tests {
+		sr := tracetest.NewSpanRecorder()
+		h := &RedisHook{Tracer: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("redis")}
+		n := &redisNodeHook{hook: h, cluster: tt.cluster}
+		n.addr.Store("127.0.0.1:7000")
+
+		start := time.Now().Add(-time.Millisecond)
+		n.recordRedirect(context.Background(), tt.err, start)
+		spans := endedSpans(sr, "redis.redirect")
+		if tt.want == nil {
+			if len(spans) != 0 {
+				t.Errorf("%s: created %d redirect spans, want none", tt.name, len(spans))
+			}
+			continue
+		}
+		if len(spans) != 1 {
+			t.Fatalf("%s: created %d redirect spans, want 1", tt.name, len(spans))
+		}
+		if !spans[0].StartTime().Equal(start) {
+			t.Errorf("%s: start = %v, want the command start %v", tt.name, spans[0].StartTime(), start)
+		}
+		for key, want := range tt.want {
+			if v, _ := spanAttr(spans[0], key); v != want {
+				t.Errorf("%s: %s = %q, want %q", tt.name, key, v, want)
+			}
+		}
+	}
+}
+
+// liveShards 返回 Ring 中在线的分片数
+func liveShards(ring *redis.Ring) int {
+	var n atomic.Int64
+	_ = ring.ForEachShard(context.Background(), func(context.Context, *redis.Client) error {
+		n.Add(1)
+		return nil
+	})
+	return int(n.Load())
+}
+
+// waitFor 轮询直到 cond 成立
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRedisRingInstrumentDownShard(t *testing.T) {
+	up := newRESPServer(t)
+	// 先占用端口再关闭，分片 b 在创建 Ring 时处于下线状态
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	downAddr := ln.Addr().String()
+	_ = ln.Close()
+
+	sr := tracetest.NewSpanRecorder()
+	hook := &RedisHook{Tracer: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("redis")}
+	opt := &redis.RingOptions{
+		Addrs:              map[string]string{"a": up.ln.Addr().String(), "b": downAddr},
+		HeartbeatFrequency: 10 * time.Millisecond,
+		Protocol:           2,
+		DisableIdentity:    true,
+		MaxRetries:         -1,
+	}
+	hook.InstrumentRingOptions(opt)
+	ring := redis.NewRing(opt)
+	defer ring.Close()
+	hook.Instrument(ring)
+	waitFor(t, "shard b to go down", func() bool { return liveShards(ring) == 1 })
+
+	ln, err = net.Listen("tcp", downAddr)
+	if err != nil {
+		t.Skipf("port %s reused before the shard came back: %v", downAddr, err)
+	}
+	serveRESP(t, ln)
+	waitFor(t, "shard b to come back", func() bool { return liveShards(ring) == 2 })
+
+	for i := 0; i < 50; i++ {
+		if err := ring.Set(context.Background(), fmt.Sprintf("key:%d", i), "v", 0).Err(); err != nil {
+			t.Fatalf("set: %v", err)
+		}
+	}
+
+	ports := map[string]int{}
+	for _, s := range endedSpans(sr, "redis.set") {
+		port, _ := spanAttr(s, "server.port")
+		ports[port]++
+	}
+	_, downPort, _ := net.SplitHostPort(downAddr)
+	_, upPort, _ := net.SplitHostPort(up.ln.Addr().String())
+	if ports[downPort] == 0 || ports[upPort] == 0 || ports[downPort]+ports[upPort] != 50 {
+		t.Fatalf("commands per server.port = %v, want both shards %s and %s tagged", ports, upPort, downPort)
+	}
+}
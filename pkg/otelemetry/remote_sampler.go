@@ -0,0 +1,308 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// samplingStrategyResponse Jaeger 远程采样接口的响应格式
+type samplingStrategyResponse struct {
+	StrategyType          strategyType                  `json:"strategyType"`
+	ProbabilisticSampling *probabilisticSamplingConfig  `json:"probabilisticSampling,omitempty"`
+	RateLimitingSampling  *rateLimitingSamplingConfig   `json:"rateLimitingSampling,omitempty"`
+	OperationSampling     *perOperationSamplingStrategy `json:"operationSampling,omitempty"`
+}
+
+type probabilisticSamplingConfig struct {
+	SamplingRate float64 `json:"samplingRate"`
+}
+
+type rateLimitingSamplingConfig struct {
+	MaxTracesPerSecond float64 `json:"maxTracesPerSecond"`
+}
+
+type perOperationSamplingStrategy struct {
+	DefaultSamplingProbability       float64                     `json:"defaultSamplingProbability"`
+	DefaultLowerBoundTracesPerSecond float64                     `json:"defaultLowerBoundTracesPerSecond"`
+	PerOperationStrategies           []operationSamplingStrategy `json:"perOperationStrategies"`
+}
+
+type operationSamplingStrategy struct {
+	Operation             string                       `json:"operation"`
+	ProbabilisticSampling *probabilisticSamplingConfig `json:"probabilisticSampling"`
+}
+
+// strategyType 采样策略类型，兼容字符串和数字两种写法
+type strategyType string
+
+const (
+	strategyProbabilistic strategyType = "PROBABILISTIC"
+	strategyRateLimiting  strategyType = "RATE_LIMITING"
+)
+
+// UnmarshalJSON 实现 json.Unmarshaler 接口
+func (t *strategyType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = strategyType(s)
+		return nil
+	}
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid strategyType: %s", data)
+	}
+	switch n {
+	case 0:
+		*t = strategyProbabilistic
+	case 1:
+		*t = strategyRateLimiting
+	default:
+		return fmt.Errorf("invalid strategyType: %d", n)
+	}
+	return nil
+}
+
+// remoteStrategy 由远程配置构建出的采样器
+type remoteStrategy struct {
+	defaultSampler paramSampler
+	operations     map[string]paramSampler // 按 span 名称匹配的采样器
+}
+
+// samplerParams 采样器参数
+type samplerParams struct {
+	ratio     float64 // 采样比例
+	perSecond float64 // 限流或每秒下限的 trace 数，为 0 时只按比例采样
+	burst     int     // 令牌桶容量
+}
+
+// paramSampler 带有构建参数的采样器，参数不变时可以复用
+type paramSampler struct {
+	params  samplerParams
+	sampler sdktrace.Sampler
+}
+
+// newRemoteStrategy 根据远程采样配置构建采样器
+// prev 为上一次的策略，参数没有变化的采样器会被复用，避免每次拉取后令牌桶被重置导致实际采样速率超出限制
+func newRemoteStrategy(resp *samplingStrategyResponse, prev *remoteStrategy) (*remoteStrategy, error) {
+	var prevDefault paramSampler
+	var prevOperations map[string]paramSampler
+	if prev != nil {
+		prevDefault, prevOperations = prev.defaultSampler, prev.operations
+	}
+	s := &remoteStrategy{}
+
+	// 按操作配置的采样策略优先
+	if op := resp.OperationSampling; op != nil {
+		s.defaultSampler = reuseSampler(prevDefault, operationParams(op.DefaultSamplingProbability, op.DefaultLowerBoundTracesPerSecond))
+		s.operations = make(map[string]paramSampler, len(op.PerOperationStrategies))
+		for _, o := range op.PerOperationStrategies {
+			if o.ProbabilisticSampling == nil {
+				continue
+			}
+			s.operations[o.Operation] = reuseSampler(prevOperations[o.Operation],
+				operationParams(o.ProbabilisticSampling.SamplingRate, op.DefaultLowerBoundTracesPerSecond))
+		}
+		return s, nil
+	}
+
+	switch resp.StrategyType {
+	case strategyProbabilistic:
+		if resp.ProbabilisticSampling == nil {
+			return nil, fmt.Errorf("missing probabilisticSampling")
+		}
+		s.defaultSampler = reuseSampler(prevDefault, samplerParams{ratio: resp.ProbabilisticSampling.SamplingRate})
+	case strategyRateLimiting:
+		if resp.RateLimitingSampling == nil {
+			return nil, fmt.Errorf("missing rateLimitingSampling")
+		}
+		s.defaultSampler = reuseSampler(prevDefault, samplerParams{perSecond: resp.RateLimitingSampling.MaxTracesPerSecond})
+	default:
+		return nil, fmt.Errorf("unsupported strategyType: %q", resp.StrategyType)
+	}
+	return s, nil
+}
+
+// operationParams 返回单个操作的采样器参数
+// 配置了每秒下限时，先保证下限内的 trace 全部采样，超出部分再按比例采样
+func operationParams(ratio, lowerBound float64) samplerParams {
+	if lowerBound > 0 {
+		return samplerParams{ratio: ratio, perSecond: lowerBound, burst: 1}
+	}
+	return samplerParams{ratio: ratio}
+}
+
+// reuseSampler 参数与上一次相同时复用上一次的采样器，否则按参数新建
+func reuseSampler(prev paramSampler, params samplerParams) paramSampler {
+	if prev.sampler != nil && prev.params == params {
+		return prev
+	}
+	var sampler sdktrace.Sampler
+	if params.perSecond > 0 {
+		sampler = newRateLimitSampler(params.perSecond, params.burst, params.ratio)
+	} else {
+		sampler = sdktrace.TraceIDRatioBased(params.ratio)
+	}
+	return paramSampler{params: params, sampler: sampler}
+}
+
+// sampler 返回指定操作使用的采样器
+func (s *remoteStrategy) sampler(name string) sdktrace.Sampler {
+	if sampler, ok := s.operations[name]; ok {
+		return sampler.sampler
+	}
+	return s.defaultSampler.sampler
+}
+
+// remoteSampler 远程采样器
+// 定期从远程地址拉取 Jaeger 格式的采样策略，拉取失败时沿用上一次成功的策略
+// 从未成功拉取过时使用本地配置的采样器
+type remoteSampler struct {
+	url         string
+	serviceName string
+	interval    time.Duration
+	client      *http.Client
+	fallback    sdktrace.Sampler
+
+	current atomic.Pointer[remoteStrategy]
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// newRemoteSampler 创建远程采样器并开始定期拉取策略
+func newRemoteSampler(endpoint, serviceName string, interval, timeout time.Duration, fallback sdktrace.Sampler) *remoteSampler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	s := &remoteSampler{
+		url:         endpoint,
+		serviceName: serviceName,
+		interval:    interval,
+		client:      &http.Client{Timeout: timeout},
+		fallback:    fallback,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go s.poll()
+	return s
+}
+
+// ShouldSample 实现 sdktrace.Sampler 接口
+func (s *remoteSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	strategy := s.current.Load()
+	if strategy == nil {
+		return s.fallback.ShouldSample(p)
+	}
+	return strategy.sampler(p.Name).ShouldSample(p)
+}
+
+// Description 实现 sdktrace.Sampler 接口
+func (s *remoteSampler) Description() string {
+	return fmt.Sprintf("RemoteSampler{url:%s,fallback:%s}", s.url, s.fallback.Description())
+}
+
+// close 停止拉取策略
+func (s *remoteSampler) close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
+
+// poll 定期拉取采样策略
+func (s *remoteSampler) poll() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.update(); err != nil {
+			log.Printf("fetch remote sampling strategy failed: %v", err)
+		}
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update 拉取一次采样策略，成功后替换当前策略
+func (s *remoteSampler) update() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	u, err := url.Parse(s.url)
+	if err != nil {
+		return fmt.Errorf("parse url failed: %w", err)
+	}
+	q := u.Query()
+	q.Set("service", s.serviceName)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var body samplingStrategyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("decode response failed: %w", err)
+	}
+	strategy, err := newRemoteStrategy(&body, s.current.Load())
+	if err != nil {
+		return fmt.Errorf("build strategy failed: %w", err)
+	}
+	s.current.Store(strategy)
+	return nil
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// strategyServer 返回可在运行时修改响应的采样策略服务
+type strategyServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	status  int
+	body    string
+	service string
+}
+
+func newStrategyServer(t *testing.T, body string) *strategyServer {
+	s := &strategyServer{status: http.StatusOK, body: body}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.service = r.URL.Query().Get("service")
+		w.WriteHeader(s.status)
+		_, _ = w.Write([]byte(s.body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *strategyServer) set(status int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.body = status, body
+}
+
+// newTestRemoteSampler 创建不自动轮询的远程采样器，由测试调用 update 拉取策略
+func newTestRemoteSampler(url string, fallback sdktrace.Sampler) *remoteSampler {
+	return &remoteSampler{
+		url:         url,
+		serviceName: "test-service",
+		interval:    time.Hour,
+		client:      &http.Client{Timeout: time.Second},
+		fallback:    fallback,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+func sampleDecision(s sdktrace.Sampler, name string) sdktrace.SamplingDecision {
+	return s.ShouldSample(sdktrace.SamplingParameters{
+		TraceID: trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		Name:    name,
+	}).Decision
+}
+
+func TestRemoteSamplerServesStrategy(t *testing.T) {
+	srv := newStrategyServer(t, `{"strategyType":"PROBABILISTIC","probabilisticSampling":{"samplingRate":0}}`)
+	s := newTestRemoteSampler(srv.URL, sdktrace.AlwaysSample())
+
+	if err := s.update(); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	srv.mu.Lock()
+	service := srv.service
+	srv.mu.Unlock()
+	if service != "test-service" {
+		t.Errorf("service query = %q, want test-service", service)
+	}
+	if got := sampleDecision(s, "GET /users"); got != sdktrace.Drop {
+		t.Errorf("decision = %v, want Drop from remote strategy", got)
+	}
+}
+
+func TestRemoteSamplerFallback(t *testing.T) {
+	srv := newStrategyServer(t, "")
+	srv.set(http.StatusInternalServerError, "")
+	s := newTestRemoteSampler(srv.URL, sdktrace.NeverSample())
+
+	// 从未成功拉取时使用本地采样器
+	if err := s.update(); err == nil {
+		t.Fatal("update succeeded, want error")
+	}
+	if got := sampleDecision(s, "op"); got != sdktrace.Drop {
+		t.Errorf("decision = %v, want Drop from fallback", got)
+	}
+
+	// 拉取成功后使用远程策略
+	srv.set(http.StatusOK, `{"strategyType":"PROBABILISTIC","probabilisticSampling":{"samplingRate":1}}`)
+	if err := s.update(); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if got := sampleDecision(s, "op"); got != sdktrace.RecordAndSample {
+		t.Errorf("decision = %v, want RecordAndSample from remote strategy", got)
+	}
+
+	// 之后拉取失败时沿用上一次成功的策略
+	srv.set(http.StatusOK, `{not json`)
+	if err := s.update(); err == nil {
+		t.Fatal("update succeeded, want decode error")
+	}
+	if got := sampleDecision(s, "op"); got != sdktrace.RecordAndSample {
+		t.Errorf("decision = %v, want RecordAndSample from last good strategy", got)
+	}
+}
+
+func TestRemoteSamplerPerOperation(t *testing.T) {
+	srv := newStrategyServer(t, `{
+		"strategyType": 0,
+		"operationSampling": {
+			"defaultSamplingProbability": 0,
+			"perOperationStrategies": [
+				{"operation": "GET /orders", "probabilisticSampling": {"samplingRate": 1}}
+			]
+		}
+	}`)
+	s := newTestRemoteSampler(srv.URL, sdktrace.AlwaysSample())
+	if err := s.update(); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+
+	if got := sampleDecision(s, "GET /orders"); got != sdktrace.RecordAndSample {
+		t.Errorf("GET /orders decision = %v, want RecordAndSample", got)
+	}
+	if got := sampleDecision(s, "GET /users"); got != sdktrace.Drop {
+		t.Errorf("GET /users decision = %v, want Drop from default probability", got)
+	}
+}
+
+func TestRemoteSamplerKeepsUnchangedSamplers(t *testing.T) {
+	body := `{
+		"strategyType": "PROBABILISTIC",
+		"operationSampling": {
+			"defaultSamplingProbability": 0.5,
+			"defaultLowerBoundTracesPerSecond": 1,
+			"perOperationStrategies": [
+				{"operation": "a", "probabilisticSampling": {"samplingRate": 0.1}},
+				{"operation": "b", "probabilisticSampling": {"samplingRate": 0.2}}
+			]
+		}
+	}`
+	srv := newStrategyServer(t, body)
+	s := newTestRemoteSampler(srv.URL, sdktrace.AlwaysSample())
+	if err := s.update(); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	first := s.current.Load()
+
+	srv.set(http.StatusOK, `{
+		"strategyType": "PROBABILISTIC",
+		"operationSampling": {
+			"defaultSamplingProbability": 0.5,
+			"defaultLowerBoundTracesPerSecond": 1,
+			"perOperationStrategies": [
+				{"operation": "a", "probabilisticSampling": {"samplingRate": 0.1}},
+				{"operation": "b", "probabilisticSampling": {"samplingRate": 0.3}}
+			]
+		}
+	}`)
+	if err := s.update(); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	second := s.current.Load()
+
+	if second.defaultSampler.sampler != first.defaultSampler.sampler {
+		t.Error("default sampler rebuilt although its parameters did not change")
+	}
+	if second.operations["a"].sampler != first.operations["a"].sampler {
+		t.Error("sampler of operation a rebuilt although its parameters did not change")
+	}
+	if second.operations["b"].sampler == first.operations["b"].sampler {
+		t.Error("sampler of operation b reused although its rate changed")
+	}
+}
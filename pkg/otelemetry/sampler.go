@@ -0,0 +1,146 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SamplingProbabilityKey 记录在根 span 上的实际采样概率
+// 下游可以按 1/p 对采样后的数据进行外推，还原真实流量
+const SamplingProbabilityKey = attribute.Key("sampling.probability")
+
+// rateLimitSampler 基于令牌桶的限流采样器
+// 每秒最多采样 perSecond 个根 trace，令牌耗尽后按 floorRatio 保底采样
+type rateLimitSampler struct {
+	perSecond  float64
+	burst      float64
+	floorRatio float64
+	floor      sdktrace.Sampler
+	now        func() time.Time // 获取当前时间，测试时替换为可控的时钟
+
+	mu     sync.Mutex
+	tokens float64   // 当前剩余令牌数
+	last   time.Time // 上次补充令牌的时间
+
+	// 到达速率统计，用于估算实际采样概率
+	windowStart time.Time
+	windowCount int
+	lastRate    float64
+}
+
+// newRateLimitSampler 创建限流采样器
+func newRateLimitSampler(perSecond float64, burst int, floorRatio float64) *rateLimitSampler {
+	if burst <= 0 {
+		burst = int(math.Ceil(perSecond))
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	floorRatio = math.Max(0, math.Min(1, floorRatio))
+
+	now := time.Now()
+	s := &rateLimitSampler{
+		perSecond:   perSecond,
+		burst:       float64(burst),
+		floorRatio:  floorRatio,
+		tokens:      float64(burst),
+		last:        now,
+		windowStart: now,
+		now:         time.Now,
+	}
+	if floorRatio > 0 {
+		s.floor = sdktrace.TraceIDRatioBased(floorRatio)
+	}
+	return s
+}
+
+// ShouldSample 实现 sdktrace.Sampler 接口
+func (s *rateLimitSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	psc := trace.SpanContextFromContext(p.ParentContext)
+
+	s.mu.Lock()
+	now := s.now()
+	s.observe(now)
+	allowed := s.take(now)
+	probability := s.probability()
+	s.mu.Unlock()
+
+	if !allowed && s.floor != nil {
+		allowed = s.floor.ShouldSample(p).Decision == sdktrace.RecordAndSample
+	}
+	if !allowed {
+		return sdktrace.SamplingResult{
+			Decision:   sdktrace.Drop,
+			Tracestate: psc.TraceState(),
+		}
+	}
+	return sdktrace.SamplingResult{
+		Decision:   sdktrace.RecordAndSample,
+		Attributes: []attribute.KeyValue{SamplingProbabilityKey.Float64(probability)},
+		Tracestate: psc.TraceState(),
+	}
+}
+
+// Description 实现 sdktrace.Sampler 接口
+func (s *rateLimitSampler) Description() string {
+	return fmt.Sprintf("RateLimitSampler{perSecond:%g,burst:%g,floor:%g}", s.perSecond, s.burst, s.floorRatio)
+}
+
+// take 补充令牌并尝试取走一个，调用方需持有锁
+func (s *rateLimitSampler) take(now time.Time) bool {
+	elapsed := now.Sub(s.last).Seconds()
+	if elapsed > 0 {
+		s.tokens = math.Min(s.burst, s.tokens+elapsed*s.perSecond)
+		s.last = now
+	}
+	if s.tokens < 1 {
+		return false
+	}
+	s.tokens--
+	return true
+}
+
+// observe 统计根 trace 的到达速率，以 1 秒为窗口，调用方需持有锁
+func (s *rateLimitSampler) observe(now time.Time) {
+	if elapsed := now.Sub(s.windowStart); elapsed >= time.Second {
+		s.lastRate = float64(s.windowCount) / elapsed.Seconds()
+		s.windowStart = now
+		s.windowCount = 0
+	}
+	s.windowCount++
+}
+
+// probability 估算当前的实际采样概率，调用方需持有锁
+// 令牌桶命中概率为 perSecond/到达速率，未命中部分再按保底比例采样
+func (s *rateLimitSampler) probability() float64 {
+	rate := math.Max(s.lastRate, float64(s.windowCount))
+	bucket := 1.0
+	if rate > s.perSecond {
+		bucket = s.perSecond / rate
+	}
+	return bucket + (1-bucket)*s.floorRatio
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// testClock 手动推进的时钟
+type testClock struct{ now time.Time }
+
+func (c *testClock) Now() time.Time          { return c.now }
+func (c *testClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// newTestRateLimitSampler 创建使用 testClock 的限流采样器
+func newTestRateLimitSampler(perSecond float64, burst int, floorRatio float64) (*rateLimitSampler, *testClock) {
+	clock := &testClock{now: time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)}
+	s := newRateLimitSampler(perSecond, burst, floorRatio)
+	s.now = clock.Now
+	s.last = clock.now
+	s.windowStart = clock.now
+	return s, clock
+}
+
+var (
+	// lowTraceID 低 64 位为 0，任何大于 0 的比例采样都会命中
+	lowTraceID = trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8}
+	// highTraceID 低 64 位为最大值，比例小于 1 的采样都不会命中
+	highTraceID = trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+)
+
+func rateLimitSample(s sdktrace.Sampler, id trace.TraceID) sdktrace.SamplingResult {
+	return s.ShouldSample(sdktrace.SamplingParameters{TraceID: id, Name: "root"})
+}
+
+func TestRateLimitSamplerBurstAndRefill(t *testing.T) {
+	s, clock := newTestRateLimitSampler(2, 3, 0)
+
+	// 初始令牌数等于 burst
+	for i := 0; i < 3; i++ {
+		if d := rateLimitSample(s, lowTraceID).Decision; d != sdktrace.RecordAndSample {
+			t.Fatalf("sample %d within burst = %v", i, d)
+		}
+	}
+	if d := rateLimitSample(s, lowTraceID).Decision; d != sdktrace.Drop {
+		t.Fatalf("sample beyond burst = %v, want Drop", d)
+	}
+
+	// 每秒补充 2 个令牌，0.5 秒后只能再采样 1 个
+	clock.Advance(500 * time.Millisecond)
+	if d := rateLimitSample(s, lowTraceID).Decision; d != sdktrace.RecordAndSample {
+		t.Fatalf("sample after refill = %v", d)
+	}
+	if d := rateLimitSample(s, lowTraceID).Decision; d != sdktrace.Drop {
+		t.Fatalf("second sample after a half-token refill = %v, want Drop", d)
+	}
+
+	// 长时间空闲后令牌数不超过 burst
+	clock.Advance(time.Minute)
+	sampled := 0
+	for i := 0; i < 10; i++ {
+		if rateLimitSample(s, lowTraceID).Decision == sdktrace.RecordAndSample {
+			sampled++
+		}
+	}
+	if sampled != 3 {
+		t.Fatalf("sampled %d after idling, want burst 3", sampled)
+	}
+}
+
+func TestRateLimitSamplerDefaultBurst(t *testing.T) {
+	s, _ := newTestRateLimitSampler(2.5, 0, 0)
+	if s.burst != 3 {
+		t.Fatalf("burst = %v, want ceil(perSecond) = 3", s.burst)
+	}
+	s, _ = newTestRateLimitSampler(0.1, 0, 0)
+	if s.burst != 1 {
+		t.Fatalf("burst = %v, want at least 1", s.burst)
+	}
+}
+
+func TestRateLimitSamplerFloorRatio(t *testing.T) {
+	tests := []struct {
+		name  string
+		floor float64
+		id    trace.TraceID
+		want  sdktrace.SamplingDecision
+	}{
+		{"no floor", 0, lowTraceID, sdktrace.Drop},
+		{"full floor", 1, highTraceID, sdktrace.RecordAndSample},
+		{"partial floor hit", 0.5, lowTraceID, sdktrace.RecordAndSample},
+		{"partial floor miss", 0.5, highTraceID, sdktrace.Drop},
+	}
+	for _, tt := range tests {
+		s, _ := newTestRateLimitSampler(1, 1, tt.floor)
+		if d := rateLimitSample(s, tt.id).Decision; d != sdktrace.RecordAndSample {
+			t.Fatalf("%s: first sample = %v", tt.name, d)
+		}
+		if d := rateLimitSample(s, tt.id).Decision; d != tt.want {
+			t.Errorf("%s: sample after the bucket is empty = %v, want %v", tt.name, d, tt.want)
+		}
+	}
+}
+
+func TestRateLimitSamplerProbabilityAttribute(t *testing.T) {
+	s, clock := newTestRateLimitSampler(2, 2, 0.5)
+	probability := func(r sdktrace.SamplingResult) float64 {
+		for _, kv := range r.Attributes {
+			if kv.Key == SamplingProbabilityKey {
+				return kv.Value.AsFloat64()
+			}
+		}
+		t.Fatalf("sampled result without %s", SamplingProbabilityKey)
+		return 0
+	}
+
+	// 到达速率不超过 perSecond 时概率为 1
+	for i := 0; i < 2; i++ {
+		if p := probability(rateLimitSample(s, lowTraceID)); p != 1 {
+			t.Fatalf("probability within the limit = %v, want 1", p)
+		}
+	}
+	// 第 3 个 trace：令牌桶命中概率 2/3，未命中部分按 0.5 保底
+	want := 2.0/3 + (1-2.0/3)*0.5
+	r := rateLimitSample(s, lowTraceID)
+	if r.Decision != sdktrace.RecordAndSample {
+		t.Fatalf("floor sample = %v", r.Decision)
+	}
+	if p := probability(r); math.Abs(p-want) > 1e-9 {
+		t.Fatalf("probability = %v, want %v", p, want)
+	}
+	// 新窗口沿用上一个窗口的到达速率 3/s
+	clock.Advance(time.Second)
+	if p := probability(rateLimitSample(s, lowTraceID)); math.Abs(p-want) > 1e-9 {
+		t.Fatalf("probability in the next window = %v, want %v", p, want)
+	}
+
+	// 用完剩下的令牌后，未命中保底的 trace 被丢弃且不带属性
+	rateLimitSample(s, lowTraceID)
+	if r := rateLimitSample(s, highTraceID); r.Decision != sdktrace.Drop || len(r.Attributes) != 0 {
+		t.Fatalf("result = %v with attributes %v, want Drop without attributes", r.Decision, r.Attributes)
+	}
+}
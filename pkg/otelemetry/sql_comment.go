@@ -0,0 +1,114 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// SQLCommenter sqlcommenter 配置
+// 启用后会在发出的 SQL 末尾追加 /*key='value',...*/ 格式的注释，数据库的慢日志中即可看到 trace ID
+// 预编译语句会被驱动缓存复用，不追加注释
+type SQLCommenter struct {
+	Service string // 服务名称，为空时不追加 service 标签
+}
+
+// SQLCommentTags 追加到 SQL 注释中的业务标签
+type SQLCommentTags struct {
+	Route      string // 请求路由，如 /api/users/{id}
+	Controller string // 处理请求的控制器或方法名
+}
+
+// sqlCommentTagsKey 上下文中保存业务标签的键
+const sqlCommentTagsKey = contextKey("sql_comment_tags")
+
+// ContextWithSQLCommentTags 返回带有业务标签的上下文，之后以该上下文执行的 SQL 会带上这些标签
+func ContextWithSQLCommentTags(ctx context.Context, tags SQLCommentTags) context.Context {
+	return context.WithValue(ctx, sqlCommentTagsKey, tags)
+}
+
+// SQLCommentTagsFromContext 返回上下文中的业务标签
+func SQLCommentTagsFromContext(ctx context.Context) SQLCommentTags {
+	tags, _ := ctx.Value(sqlCommentTagsKey).(SQLCommentTags)
+	return tags
+}
+
+// comment 为 SQL 追加 sqlcommenter 注释
+// SQL 中已经有注释时不再追加，避免破坏提示（hint）或重复追加
+func (c *SQLCommenter) comment(ctx context.Context, query string) string {
+	if c == nil || query == "" || strings.Contains(query, "/*") {
+		return query
+	}
+
+	tags := make(map[string]string, 4)
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	for _, key := range []string{"traceparent", "tracestate"} {
+		if v := carrier.Get(key); v != "" {
+			tags[key] = v
+		}
+	}
+	t := SQLCommentTagsFromContext(ctx)
+	if t.Route != "" {
+		tags["route"] = t.Route
+	}
+	if t.Controller != "" {
+		tags["controller"] = t.Controller
+	}
+	if c.Service != "" {
+		tags["service"] = c.Service
+	}
+	if len(tags) == 0 {
+		return query
+	}
+	return query + " " + formatSQLComment(tags)
+}
+
+// formatSQLComment 按 sqlcommenter 规范格式化注释：键按字典序排列，键和值做 URL 编码，值用单引号包裹
+func formatSQLComment(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("/*")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(sqlCommentEscape(k))
+		b.WriteString("='")
+		b.WriteString(sqlCommentEscape(tags[k]))
+		b.WriteByte('\'')
+	}
+	b.WriteString("*/")
+	return b.String()
+}
+
+// sqlCommentEscape 对键和值做 URL 编码，单引号会被编码为 %27，空格编码为 %20
+func sqlCommentEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,326 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"strings"
+)
+
+// SQLStatementMode 定义 db.statement 的记录方式
+type SQLStatementMode int
+
+const (
+	// SQLStatementParameterized 只记录带占位符的 SQL，不包含参数值（默认）
+	SQLStatementParameterized SQLStatementMode = iota
+	// SQLStatementFull 记录代入参数值后的完整 SQL，可能包含敏感数据
+	SQLStatementFull
+	// SQLStatementObfuscated 记录脱敏后的 SQL，字符串、数字字面量和 IN 列表都会替换为 ?，注释会被删除
+	SQLStatementObfuscated
+)
+
+// sqlTokenKind SQL 词法单元类型
+type sqlTokenKind int
+
+const (
+	sqlTokenOther       sqlTokenKind = iota // 运算符、标点等
+	sqlTokenSpace                           // 空白
+	sqlTokenComment                         // 注释
+	sqlTokenIdent                           // 标识符和关键字
+	sqlTokenLiteral                         // 字符串、数字字面量
+	sqlTokenPlaceholder                     // 参数占位符，如 ?、$1
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// sqlDialect 不同数据库的引号规则
+type sqlDialect struct {
+	doubleQuoteString bool // 双引号是否表示字符串（MySQL），否则表示标识符
+	backslashEscape   bool // 字符串中反斜杠是否转义（MySQL）
+	hashComment       bool // # 是否表示单行注释（MySQL）
+	dollarQuote       bool // 是否支持 $1 占位符和 $tag$...$tag$ 字符串（PostgreSQL）
+}
+
+// sqlDialectOf 返回 db.system 对应的引号规则
+func sqlDialectOf(system string) sqlDialect {
+	switch system {
+	case "mysql", "mariadb", "tidb":
+		return sqlDialect{doubleQuoteString: true, backslashEscape: true, hashComment: true}
+	case "postgresql", "cockroachdb", "redshift":
+		return sqlDialect{dollarQuote: true}
+	default:
+		return sqlDialect{}
+	}
+}
+
+// ObfuscateSQL 对 SQL 进行脱敏，字符串、数字字面量替换为 ?，IN 列表折叠为 IN (?)
+// 注释可能包含业务数据（如 /* user=alice */），整体删除，与前后的词法单元之间最多保留一个空格
+// system: 数据库类型（db.system），决定引号和注释的解析规则，支持 mysql 和 postgresql
+func ObfuscateSQL(sql, system string) string {
+	tokens := tokenizeSQL(sql, sqlDialectOf(system))
+
+	var b strings.Builder
+	b.Grow(len(sql))
+	dropped := false  // 是否删除过注释
+	separate := false // 刚删除了注释，后面的内容需要与前面的内容分隔
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if t.kind == sqlTokenComment {
+			dropped = true
+			separate = b.Len() > 0
+			continue
+		}
+		if separate {
+			separate = false
+			if t.kind == sqlTokenSpace {
+				if last := b.String()[b.Len()-1]; isSQLSpace(last) {
+					continue
+				}
+			} else if last := b.String()[b.Len()-1]; !isSQLSpace(last) {
+				b.WriteByte(' ')
+			}
+		}
+		switch t.kind {
+		case sqlTokenLiteral, sqlTokenPlaceholder:
+			b.WriteByte('?')
+		case sqlTokenIdent:
+			b.WriteString(t.text)
+			if strings.EqualFold(t.text, "IN") {
+				if end := inListEnd(tokens, i+1); end > 0 {
+					b.WriteString(" (?)")
+					i = end
+				}
+			}
+		default:
+			b.WriteString(t.text)
+		}
+	}
+	// 开头和末尾的注释删除后不保留它们旁边的空白
+	if dropped {
+		return strings.TrimSpace(b.String())
+	}
+	return b.String()
+}
+
+// inListEnd 判断 IN 后面是否是只包含字面量和占位符的列表，是则返回右括号的位置
+func inListEnd(tokens []sqlToken, i int) int {
+	for i < len(tokens) && tokens[i].kind == sqlTokenSpace {
+		i++
+	}
+	if i >= len(tokens) || tokens[i].text != "(" {
+		return -1
+	}
+	values := 0
+	for i++; i < len(tokens); i++ {
+		switch t := tokens[i]; {
+		case t.kind == sqlTokenLiteral || t.kind == sqlTokenPlaceholder:
+			values++
+		case t.kind == sqlTokenSpace || t.text == ",":
+		case t.text == ")":
+			if values == 0 {
+				return -1
+			}
+			return i
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+// tokenizeSQL 将 SQL 切分为词法单元
+func tokenizeSQL(sql string, d sqlDialect) []sqlToken {
+	var tokens []sqlToken
+	emit := func(kind sqlTokenKind, start, end int) {
+		tokens = append(tokens, sqlToken{kind: kind, text: sql[start:end]})
+	}
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		start := i
+		switch {
+		case isSQLSpace(c):
+			for i < len(sql) && isSQLSpace(sql[i]) {
+				i++
+			}
+			emit(sqlTokenSpace, start, i)
+
+		case c == '-' && strings.HasPrefix(sql[i:], "--"), c == '#' && d.hashComment:
+			i = lineEnd(sql, i)
+			emit(sqlTokenComment, start, i)
+
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(sql)
+			}
+			emit(sqlTokenComment, start, i)
+
+		case c == '\'':
+			i = quotedEnd(sql, i, '\'', d.backslashEscape)
+			emit(sqlTokenLiteral, start, i)
+
+		case c == '"':
+			i = quotedEnd(sql, i, '"', d.backslashEscape && d.doubleQuoteString)
+			if d.doubleQuoteString {
+				emit(sqlTokenLiteral, start, i)
+			} else {
+				emit(sqlTokenIdent, start, i)
+			}
+
+		case c == '`':
+			i = quotedEnd(sql, i, '`', false)
+			emit(sqlTokenIdent, start, i)
+
+		case c == '?':
+			i++
+			emit(sqlTokenPlaceholder, start, i)
+
+		case c == '$' && d.dollarQuote:
+			if end := dollarQuotedEnd(sql, i); end > 0 {
+				i = end
+				emit(sqlTokenLiteral, start, i)
+				break
+			}
+			i++
+			for i < len(sql) && isSQLDigit(sql[i]) {
+				i++
+			}
+			if i-start > 1 {
+				emit(sqlTokenPlaceholder, start, i)
+			} else {
+				emit(sqlTokenOther, start, i)
+			}
+
+		case isSQLDigit(c) || (c == '.' && i+1 < len(sql) && isSQLDigit(sql[i+1])):
+			i = numberEnd(sql, i)
+			emit(sqlTokenLiteral, start, i)
+
+		case isSQLIdentStart(c):
+			for i < len(sql) && isSQLIdentPart(sql[i]) {
+				i++
+			}
+			// 带前缀的字符串，如 N'abc'、X'0F'、B'01'、E'a\nb'
+			if i-start == 1 && i < len(sql) && sql[i] == '\'' && strings.ContainsRune("nNxXbBeE", rune(c)) {
+				backslash := d.backslashEscape || c == 'e' || c == 'E'
+				i = quotedEnd(sql, i, '\'', backslash)
+				emit(sqlTokenLiteral, start, i)
+				break
+			}
+			emit(sqlTokenIdent, start, i)
+
+		default:
+			i++
+			emit(sqlTokenOther, start, i)
+		}
+	}
+	return tokens
+}
+
+// quotedEnd 返回从 i 开始的引号内容结束后的位置，支持引号重复和反斜杠两种转义
+func quotedEnd(sql string, i int, quote byte, backslash bool) int {
+	for i++; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if backslash {
+				i++
+			}
+		case quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+// dollarQuotedEnd 解析 PostgreSQL 的 $tag$...$tag$ 字符串，不是该格式时返回 -1
+func dollarQuotedEnd(sql string, i int) int {
+	j := i + 1
+	for j < len(sql) && sql[j] != '$' {
+		if !isSQLIdentStart(sql[j]) && !(j > i+1 && isSQLDigit(sql[j])) {
+			return -1
+		}
+		j++
+	}
+	if j >= len(sql) {
+		return -1
+	}
+	tag := sql[i : j+1]
+	end := strings.Index(sql[j+1:], tag)
+	if end < 0 {
+		return len(sql)
+	}
+	return j + 1 + end + len(tag)
+}
+
+// numberEnd 返回数字字面量结束的位置，支持十六进制、小数和科学计数法
+func numberEnd(sql string, i int) int {
+	if strings.HasPrefix(sql[i:], "0x") || strings.HasPrefix(sql[i:], "0X") {
+		i += 2
+		for i < len(sql) && strings.IndexByte("0123456789abcdefABCDEF", sql[i]) >= 0 {
+			i++
+		}
+		return i
+	}
+	for i < len(sql) && (isSQLDigit(sql[i]) || sql[i] == '.') {
+		i++
+	}
+	if i < len(sql) && (sql[i] == 'e' || sql[i] == 'E') {
+		j := i + 1
+		if j < len(sql) && (sql[j] == '+' || sql[j] == '-') {
+			j++
+		}
+		if j < len(sql) && isSQLDigit(sql[j]) {
+			for i = j; i < len(sql) && isSQLDigit(sql[i]); i++ {
+			}
+		}
+	}
+	return i
+}
+
+// lineEnd 返回单行注释结束的位置（不包含换行符）
+func lineEnd(sql string, i int) int {
+	if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+		return i + end
+	}
+	return len(sql)
+}
+
+func isSQLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isSQLDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isSQLIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+// isSQLIdentPart MySQL 和 PostgreSQL 都允许 $ 出现在标识符中间
+func isSQLIdentPart(c byte) bool {
+	return isSQLIdentStart(c) || isSQLDigit(c) || c == '$'
+}
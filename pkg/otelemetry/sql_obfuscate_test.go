@@ -0,0 +1,85 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import "testing"
+
+func TestObfuscateSQL(t *testing.T) {
+	tests := []struct {
+		name   string
+		system string
+		sql    string
+		want   string
+	}{
+		// 字面量
+		{"string", "", "SELECT * FROM users WHERE name = 'bob'", "SELECT * FROM users WHERE name = ?"},
+		{"doubled quote", "", "SELECT 'it''s', 1", "SELECT ?, ?"},
+		{"numbers", "", "SELECT 42, 3.14, .5, 1e10, 2E-3, 0x1F", "SELECT ?, ?, ?, ?, ?, ?"},
+		{"placeholder", "", "UPDATE users SET name = ? WHERE id = ?", "UPDATE users SET name = ? WHERE id = ?"},
+		{"identifier with digits", "", "SELECT col1 FROM t2", "SELECT col1 FROM t2"},
+		{"backtick identifier", "mysql", "SELECT `user 1` FROM `t`", "SELECT `user 1` FROM `t`"},
+
+		// MySQL：反斜杠转义、双引号字符串、# 注释
+		{"mysql backslash", "mysql", `SELECT 'a\'b', 'c'`, "SELECT ?, ?"},
+		{"mysql double quote string", "mysql", `SELECT * FROM t WHERE a = "x\"y"`, "SELECT * FROM t WHERE a = ?"},
+		{"mysql hash comment", "mysql", "SELECT 1 # user=alice\nFROM t", "SELECT ? FROM t"},
+		{"postgres hash is operator", "postgresql", "SELECT a # b FROM t", "SELECT a # b FROM t"},
+
+		// 非 MySQL：反斜杠不转义，双引号是标识符
+		{"standard backslash", "", `SELECT 'a\', 'b'`, "SELECT ?, ?"},
+		{"standard double quote identifier", "postgresql", `SELECT "Name" FROM "Users" WHERE id = 1`, `SELECT "Name" FROM "Users" WHERE id = ?`},
+
+		// PostgreSQL：$n 占位符和 $tag$ 字符串
+		{"postgres positional", "postgresql", "SELECT * FROM t WHERE a = $1 AND b = $12", "SELECT * FROM t WHERE a = ? AND b = ?"},
+		{"postgres dollar quote", "postgresql", "SELECT $$it's$$, $body$x $$ y$body$", "SELECT ?, ?"},
+		{"postgres unterminated dollar quote", "postgresql", "SELECT $tag$secret", "SELECT ?"},
+		{"postgres dollar in identifier", "postgresql", "SELECT a$b FROM t", "SELECT a$b FROM t"},
+
+		// 带前缀的字符串
+		{"escape string", "postgresql", `SELECT E'a\'b', 'c'`, "SELECT ?, ?"},
+		{"national string", "", "SELECT N'名字'", "SELECT ?"},
+		{"hex string", "", "SELECT X'0F', x'ff'", "SELECT ?, ?"},
+		{"bit string", "", "SELECT B'0101'", "SELECT ?"},
+		{"prefix is identifier", "", "SELECT e FROM n", "SELECT e FROM n"},
+
+		// IN 列表折叠
+		{"in list", "", "SELECT * FROM t WHERE id IN (1, 2, 3)", "SELECT * FROM t WHERE id IN (?)"},
+		{"in list placeholders", "postgresql", "SELECT * FROM t WHERE id in ($1,$2)", "SELECT * FROM t WHERE id in (?)"},
+		{"in list strings", "", "DELETE FROM t WHERE name IN ('a','b')", "DELETE FROM t WHERE name IN (?)"},
+		{"in subquery", "", "SELECT * FROM t WHERE id IN (SELECT id FROM u WHERE x = 1)", "SELECT * FROM t WHERE id IN (SELECT id FROM u WHERE x = ?)"},
+		{"in column list", "", "SELECT * FROM t WHERE 1 IN (a, b)", "SELECT * FROM t WHERE ? IN (a, b)"},
+		{"in empty list", "", "SELECT * FROM t WHERE id IN ()", "SELECT * FROM t WHERE id IN ()"},
+
+		// 注释整体删除
+		{"block comment", "", "SELECT /* user=alice */ name FROM t", "SELECT name FROM t"},
+		{"block comment between tokens", "", "SELECT a/*x*/FROM t", "SELECT a FROM t"},
+		{"line comment", "", "SELECT 1 -- token=secret\nFROM t", "SELECT ? FROM t"},
+		{"leading comment", "", "/* trace */ SELECT 1", "SELECT ?"},
+		{"trailing comment", "", "SELECT 1 /*traceparent='00-abc-01'*/", "SELECT ?"},
+		{"unterminated comment", "", "SELECT 1 /* secret", "SELECT ?"},
+		{"comment markers in string", "", "SELECT '-- not a comment', '/* nor this */'", "SELECT ?, ?"},
+
+		{"empty", "", "", ""},
+	}
+	for _, tt := range tests {
+		if got := ObfuscateSQL(tt.sql, tt.system); got != tt.want {
+			t.Errorf("%s: ObfuscateSQL(%q, %q) = %q, want %q", tt.name, tt.sql, tt.system, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,490 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"encoding/binary"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TailSamplingPolicy 尾部采样策略
+// 在决策窗口结束后，根据整条 trace 已结束的 span 判断是否保留
+type TailSamplingPolicy interface {
+	// Name 返回策略名称
+	Name() string
+	// Keep 返回 true 表示保留该 trace
+	Keep(spans []sdktrace.ReadOnlySpan) bool
+}
+
+// tailPolicy 基于函数实现的尾部采样策略
+type tailPolicy struct {
+	name string
+	keep func(spans []sdktrace.ReadOnlySpan) bool
+}
+
+func (p *tailPolicy) Name() string {
+	return p.name
+}
+
+func (p *tailPolicy) Keep(spans []sdktrace.ReadOnlySpan) bool {
+	return p.keep(spans)
+}
+
+// ErrorPolicy 保留包含错误状态 span 的 trace
+func ErrorPolicy() TailSamplingPolicy {
+	return &tailPolicy{
+		name: "error",
+		keep: func(spans []sdktrace.ReadOnlySpan) bool {
+			for _, s := range spans {
+				if s.Status().Code == codes.Error {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// LatencyPolicy 保留包含慢 span 的 trace
+// threshold: 任意 span 的耗时达到该阈值即保留
+func LatencyPolicy(threshold time.Duration) TailSamplingPolicy {
+	return &tailPolicy{
+		name: "latency",
+		keep: func(spans []sdktrace.ReadOnlySpan) bool {
+			for _, s := range spans {
+				if s.EndTime().Sub(s.StartTime()) >= threshold {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// AttributePolicy 保留包含指定属性的 trace
+// key: 属性名
+// values: 属性值，为空时只要存在该属性即保留
+func AttributePolicy(key string, values ...string) TailSamplingPolicy {
+	return &tailPolicy{
+		name: "attribute",
+		keep: func(spans []sdktrace.ReadOnlySpan) bool {
+			for _, s := range spans {
+				for _, kv := range s.Attributes() {
+					if string(kv.Key) != key {
+						continue
+					}
+					if len(values) == 0 {
+						return true
+					}
+					for _, v := range values {
+						if kv.Value.Emit() == v {
+							return true
+						}
+					}
+				}
+			}
+			return false
+		},
+	}
+}
+
+// ProbabilisticPolicy 按比例保留 trace，通常作为兜底策略
+// ratio: 保留比例，范围 0.0-1.0，按 trace ID 计算，同一 trace 的结果始终一致
+func ProbabilisticPolicy(ratio float64) TailSamplingPolicy {
+	bound := uint64(ratio * (1 << 63))
+	return &tailPolicy{
+		name: "probabilistic",
+		keep: func(spans []sdktrace.ReadOnlySpan) bool {
+			if len(spans) == 0 || ratio <= 0 {
+				return false
+			}
+			if ratio >= 1 {
+				return true
+			}
+			id := spans[0].SpanContext().TraceID()
+			return binary.BigEndian.Uint64(id[8:16])>>1 < bound
+		},
+	}
+}
+
+// TailSamplingOption 尾部采样配置选项
+type TailSamplingOption func(*tailSamplingOptions)
+
+// tailSamplingOptions 尾部采样内部配置
+type tailSamplingOptions struct {
+	decisionWait     time.Duration        // 决策窗口，trace 的第一个 span 结束后等待多久再做决策
+	maxTraces        int                  // 最多缓存的 trace 数，超出后提前对最早的 trace 做决策
+	maxSpansPerTrace int                  // 单条 trace 最多缓存的 span 数，超出的 span 直接丢弃
+	policies         []TailSamplingPolicy // 采样策略，任意一个策略命中即保留
+	meter            metric.Meter         // 上报统计指标的 Meter，为 nil 时只能通过 Stats() 查看
+}
+
+// WithTailDecisionWait 设置决策窗口
+// wait: trace 的第一个 span 结束后等待多久再做决策
+func WithTailDecisionWait(wait time.Duration) TailSamplingOption {
+	return func(o *tailSamplingOptions) {
+		o.decisionWait = wait
+	}
+}
+
+// WithTailMaxTraces 设置最多缓存的 trace 数
+// n: 超出后提前对最早的 trace 做决策并计入驱逐数
+func WithTailMaxTraces(n int) TailSamplingOption {
+	return func(o *tailSamplingOptions) {
+		o.maxTraces = n
+	}
+}
+
+// WithTailMaxSpansPerTrace 设置单条 trace 最多缓存的 span 数
+// n: 超出的 span 直接丢弃
+func WithTailMaxSpansPerTrace(n int) TailSamplingOption {
+	return func(o *tailSamplingOptions) {
+		o.maxSpansPerTrace = n
+	}
+}
+
+// WithTailPolicies 设置采样策略
+// policies: 任意一个策略命中即保留，未配置任何策略时保留全部 trace
+func WithTailPolicies(policies ...TailSamplingPolicy) TailSamplingOption {
+	return func(o *tailSamplingOptions) {
+		o.policies = append(o.policies, policies...)
+	}
+}
+
+// WithTailMeter 设置上报统计指标的 Meter
+// 设置后 Stats() 中的统计值会以 tail_sampling.* 指标上报
+func WithTailMeter(meter metric.Meter) TailSamplingOption {
+	return func(o *tailSamplingOptions) {
+		o.meter = meter
+	}
+}
+
+// TailSamplingStats 尾部采样统计信息
+type TailSamplingStats struct {
+	PendingTraces int    // 当前等待决策的 trace 数
+	TracesKept    uint64 // 保留的 trace 数
+	TracesDropped uint64 // 丢弃的 trace 数
+	TracesEvicted uint64 // 因超出 maxTraces 被提前决策的 trace 数
+	SpansDropped  uint64 // 因超出 maxSpansPerTrace 或 trace 被丢弃而丢弃的 span 数
+	LateSpans     uint64 // 决策之后才结束的 span 数
+}
+
+// tailTrace 等待决策的 trace
+type tailTrace struct {
+	id        trace.TraceID
+	firstSeen time.Time
+	spans     []sdktrace.ReadOnlySpan
+}
+
+// TailSamplingProcessor 尾部采样 span 处理器
+// 按 trace ID 缓存已结束的 span，在决策窗口结束后根据策略决定是否转发给下游处理器（通常是批处理导出器）
+// 注意：头部采样必须保留这些 trace（samplingRatio 设为 1.0），否则 span 根本不会到达这里
+type TailSamplingProcessor struct {
+	next sdktrace.SpanProcessor
+	opts *tailSamplingOptions
+
+	mu      sync.Mutex
+	traces  map[trace.TraceID]*tailTrace
+	queue   []*tailTrace // 按到达顺序排列，决策窗口相同，队首总是最早到期
+	decided map[trace.TraceID]bool
+	history []trace.TraceID // 已决策 trace 的先进先出记录，用于限制 decided 大小
+
+	kept, dropped, evicted, spansDropped, late atomic.Uint64
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewTailSamplingProcessor 创建尾部采样处理器
+// next: 保留的 span 会转发给该处理器
+func NewTailSamplingProcessor(next sdktrace.SpanProcessor, opts ...TailSamplingOption) *TailSamplingProcessor {
+	o := &tailSamplingOptions{
+		decisionWait:     10 * time.Second,
+		maxTraces:        10000,
+		maxSpansPerTrace: 1000,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.decisionWait <= 0 {
+		o.decisionWait = 10 * time.Second
+	}
+	if o.maxTraces <= 0 {
+		o.maxTraces = 10000
+	}
+	if o.maxSpansPerTrace <= 0 {
+		o.maxSpansPerTrace = 1000
+	}
+
+	p := &TailSamplingProcessor{
+		next:    next,
+		opts:    o,
+		traces:  make(map[trace.TraceID]*tailTrace),
+		decided: make(map[trace.TraceID]bool),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if o.meter != nil {
+		if err := p.registerMetrics(o.meter); err != nil {
+			log.Printf("register tail sampling metrics failed: %v", err)
+		}
+	}
+	go p.loop()
+	return p
+}
+
+// OnStart 实现 sdktrace.SpanProcessor 接口
+func (p *TailSamplingProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd 实现 sdktrace.SpanProcessor 接口，缓存 span 等待决策
+func (p *TailSamplingProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	id := s.SpanContext().TraceID()
+
+	p.mu.Lock()
+	// 决策之后才结束的 span 沿用之前的决策
+	if keep, ok := p.decided[id]; ok {
+		p.mu.Unlock()
+		p.late.Add(1)
+		if keep {
+			p.next.OnEnd(s)
+		} else {
+			p.spansDropped.Add(1)
+		}
+		return
+	}
+
+	var evicted []tailDecision
+	t, ok := p.traces[id]
+	if !ok {
+		for len(p.traces) >= p.opts.maxTraces {
+			evicted = append(evicted, p.decideLocked())
+		}
+		t = &tailTrace{id: id, firstSeen: time.Now()}
+		p.traces[id] = t
+		p.queue = append(p.queue, t)
+	}
+	if len(t.spans) < p.opts.maxSpansPerTrace {
+		t.spans = append(t.spans, s)
+	} else {
+		p.spansDropped.Add(1)
+	}
+	p.mu.Unlock()
+
+	p.evicted.Add(uint64(len(evicted)))
+	p.forward(evicted)
+}
+
+// Shutdown 实现 sdktrace.SpanProcessor 接口，对所有等待中的 trace 立即决策
+func (p *TailSamplingProcessor) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+	<-p.done
+	p.flush()
+	return p.next.Shutdown(ctx)
+}
+
+// ForceFlush 实现 sdktrace.SpanProcessor 接口，对所有等待中的 trace 立即决策
+func (p *TailSamplingProcessor) ForceFlush(ctx context.Context) error {
+	p.flush()
+	return p.next.ForceFlush(ctx)
+}
+
+// Stats 返回尾部采样统计信息
+func (p *TailSamplingProcessor) Stats() TailSamplingStats {
+	p.mu.Lock()
+	pending := len(p.traces)
+	p.mu.Unlock()
+
+	return TailSamplingStats{
+		PendingTraces: pending,
+		TracesKept:    p.kept.Load(),
+		TracesDropped: p.dropped.Load(),
+		TracesEvicted: p.evicted.Load(),
+		SpansDropped:  p.spansDropped.Load(),
+		LateSpans:     p.late.Load(),
+	}
+}
+
+// loop 定期对到期的 trace 做决策
+func (p *TailSamplingProcessor) loop() {
+	defer close(p.done)
+
+	interval := time.Second
+	if p.opts.decisionWait < interval {
+		interval = p.opts.decisionWait
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-ticker.C:
+			var expired []tailDecision
+			p.mu.Lock()
+			for len(p.queue) > 0 && now.Sub(p.queue[0].firstSeen) >= p.opts.decisionWait {
+				expired = append(expired, p.decideLocked())
+			}
+			p.mu.Unlock()
+			p.forward(expired)
+		}
+	}
+}
+
+// flush 对所有等待中的 trace 立即决策
+func (p *TailSamplingProcessor) flush() {
+	var pending []tailDecision
+	p.mu.Lock()
+	for len(p.queue) > 0 {
+		pending = append(pending, p.decideLocked())
+	}
+	p.mu.Unlock()
+	p.forward(pending)
+}
+
+// tailDecision trace 的采样决策
+type tailDecision struct {
+	trace *tailTrace
+	keep  bool
+}
+
+// decideLocked 取出最早到达的 trace 并做决策，调用方需持有锁
+// 取出和记录决策在同一把锁内完成，期间结束的 span 不会生成新的 trace 片段而得到不一致的决策
+func (p *TailSamplingProcessor) decideLocked() tailDecision {
+	t := p.queue[0]
+	p.queue[0] = nil
+	p.queue = p.queue[1:]
+	delete(p.traces, t.id)
+
+	keep := p.keep(t.spans)
+	p.decided[t.id] = keep
+	p.history = append(p.history, t.id)
+	for len(p.history) > p.opts.maxTraces {
+		delete(p.decided, p.history[0])
+		p.history = p.history[1:]
+	}
+	return tailDecision{trace: t, keep: keep}
+}
+
+// forward 将保留的 span 转发给下游处理器，需在锁外调用
+func (p *TailSamplingProcessor) forward(decisions []tailDecision) {
+	for _, d := range decisions {
+		if !d.keep {
+			p.dropped.Add(1)
+			p.spansDropped.Add(uint64(len(d.trace.spans)))
+			continue
+		}
+		p.kept.Add(1)
+		for _, s := range d.trace.spans {
+			p.next.OnEnd(s)
+		}
+	}
+}
+
+// registerMetrics 将统计信息注册为可观测指标，采集时读取当前值
+func (p *TailSamplingProcessor) registerMetrics(meter metric.Meter) error {
+	pending, err := meter.Int64ObservableGauge("tail_sampling.traces.pending",
+		metric.WithDescription("当前等待决策的 trace 数"),
+		metric.WithUnit("{trace}"))
+	if err != nil {
+		return err
+	}
+	kept, err := meter.Int64ObservableCounter("tail_sampling.traces.kept",
+		metric.WithDescription("保留的 trace 总数"),
+		metric.WithUnit("{trace}"))
+	if err != nil {
+		return err
+	}
+	dropped, err := meter.Int64ObservableCounter("tail_sampling.traces.dropped",
+		metric.WithDescription("丢弃的 trace 总数"),
+		metric.WithUnit("{trace}"))
+	if err != nil {
+		return err
+	}
+	evicted, err := meter.Int64ObservableCounter("tail_sampling.traces.evicted",
+		metric.WithDescription("因超出最大缓存数被提前决策的 trace 总数"),
+		metric.WithUnit("{trace}"))
+	if err != nil {
+		return err
+	}
+	spansDropped, err := meter.Int64ObservableCounter("tail_sampling.spans.dropped",
+		metric.WithDescription("丢弃的 span 总数"),
+		metric.WithUnit("{span}"))
+	if err != nil {
+		return err
+	}
+	late, err := meter.Int64ObservableCounter("tail_sampling.spans.late",
+		metric.WithDescription("决策之后才结束的 span 总数"),
+		metric.WithUnit("{span}"))
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		stats := p.Stats()
+		o.ObserveInt64(pending, int64(stats.PendingTraces))
+		o.ObserveInt64(kept, int64(stats.TracesKept))
+		o.ObserveInt64(dropped, int64(stats.TracesDropped))
+		o.ObserveInt64(evicted, int64(stats.TracesEvicted))
+		o.ObserveInt64(spansDropped, int64(stats.SpansDropped))
+		o.ObserveInt64(late, int64(stats.LateSpans))
+		return nil
+	}, pending, kept, dropped, evicted, spansDropped, late)
+	return err
+}
+
+// keep 依次评估策略，任意一个命中即保留
+// 调试采样强制采样的 trace 不经过策略，始终保留
+func (p *TailSamplingProcessor) keep(spans []sdktrace.ReadOnlySpan) bool {
+	if len(p.opts.policies) == 0 || debugSampled(spans) {
+		return true
+	}
+	for _, policy := range p.opts.policies {
+		if policy.Keep(spans) {
+			return true
+		}
+	}
+	return false
+}
+
+// debugSampled 判断 trace 中是否有被调试采样强制采样的 span
+func debugSampled(spans []sdktrace.ReadOnlySpan) bool {
+	for _, s := range spans {
+		for _, kv := range s.Attributes() {
+			if kv.Key == SamplingDebugKey && kv.Value.AsBool() {
+				return true
+			}
+		}
+	}
+	return false
+}
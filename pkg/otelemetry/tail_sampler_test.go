@@ -0,0 +1,304 @@
+// Copyright (c) 2025 Taurus Team. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Author: yelei
+// Email: 61647649@qq.com
+// Date: 2025-06-13
+
+package otelemetry
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordingProcessor 记录转发过来的 span 以及 Shutdown、ForceFlush 调用
+type recordingProcessor struct {
+	mu       sync.Mutex
+	ended    []sdktrace.ReadOnlySpan
+	shutdown bool
+	flushed  int
+}
+
+func (r *recordingProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (r *recordingProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ended = append(r.ended, s)
+}
+
+func (r *recordingProcessor) Shutdown(context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.shutdown = true
+	return nil
+}
+
+func (r *recordingProcessor) ForceFlush(context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushed++
+	return nil
+}
+
+// spans 返回转发过来的 span 按 trace ID 的计数
+func (r *recordingProcessor) spans() map[trace.TraceID]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := make(map[trace.TraceID]int)
+	for _, s := range r.ended {
+		counts[s.SpanContext().TraceID()]++
+	}
+	return counts
+}
+
+// newTestTailProcessor 创建决策窗口足够长的尾部采样处理器，由测试调用 ForceFlush 触发决策
+func newTestTailProcessor(t *testing.T, opts ...TailSamplingOption) (*TailSamplingProcessor, *recordingProcessor) {
+	next := &recordingProcessor{}
+	p := NewTailSamplingProcessor(next, append([]TailSamplingOption{WithTailDecisionWait(time.Hour)}, opts...)...)
+	t.Cleanup(func() { _ = p.Shutdown(context.Background()) })
+	return p, next
+}
+
+// tailTraceID 生成低 64 位为 low 的 trace ID，用于控制比例采样的结果
+func tailTraceID(n byte, low uint64) trace.TraceID {
+	id := trace.TraceID{n}
+	binary.BigEndian.PutUint64(id[8:], low)
+	return id
+}
+
+// tailSpan 构造已结束的 span
+func tailSpan(id trace.TraceID, spanID byte, duration time.Duration, code codes.Code) sdktrace.ReadOnlySpan {
+	start := time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)
+	return tracetest.SpanStub{
+		Name: "op",
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    id,
+			SpanID:     trace.SpanID{spanID},
+			TraceFlags: trace.FlagsSampled,
+		}),
+		StartTime: start,
+		EndTime:   start.Add(duration),
+		Status:    sdktrace.Status{Code: code},
+	}.Snapshot()
+}
+
+func TestTailSamplingPolicies(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy TailSamplingPolicy
+		span   sdktrace.ReadOnlySpan
+		keep   bool
+	}{
+		{"error keeps failed traces", ErrorPolicy(), tailSpan(tailTraceID(1, 0), 1, time.Millisecond, codes.Error), true},
+		{"error drops successful traces", ErrorPolicy(), tailSpan(tailTraceID(1, 0), 1, time.Millisecond, codes.Ok), false},
+		{"latency keeps slow traces", LatencyPolicy(100 * time.Millisecond), tailSpan(tailTraceID(1, 0), 1, 200*time.Millisecond, codes.Unset), true},
+		{"latency drops fast traces", LatencyPolicy(100 * time.Millisecond), tailSpan(tailTraceID(1, 0), 1, 10*time.Millisecond, codes.Unset), false},
+		{"probabilistic keeps low trace IDs", ProbabilisticPolicy(0.5), tailSpan(tailTraceID(1, 0), 1, time.Millisecond, codes.Unset), true},
+		{"probabilistic drops high trace IDs", ProbabilisticPolicy(0.5), tailSpan(tailTraceID(1, ^uint64(0)), 1, time.Millisecond, codes.Unset), false},
+		{"probabilistic zero drops everything", ProbabilisticPolicy(0), tailSpan(tailTraceID(1, 0), 1, time.Millisecond, codes.Unset), false},
+	}
+	for _, tt := range tests {
+		p, next := newTestTailProcessor(t, WithTailPolicies(tt.policy))
+		p.OnEnd(tt.span)
+		if got := len(next.spans()); got != 0 {
+			t.Fatalf("%s: span forwarded before the decision", tt.name)
+		}
+		if err := p.ForceFlush(context.Background()); err != nil {
+			t.Fatalf("%s: flush: %v", tt.name, err)
+		}
+
+		stats := p.Stats()
+		forwarded := next.spans()[tt.span.SpanContext().TraceID()]
+		if tt.keep {
+			if forwarded != 1 || stats.TracesKept != 1 || stats.TracesDropped != 0 {
+				t.Errorf("%s: forwarded %d spans, stats %+v, want the trace kept", tt.name, forwarded, stats)
+			}
+		} else if forwarded != 0 || stats.TracesDropped != 1 || stats.SpansDropped != 1 {
+			t.Errorf("%s: forwarded %d spans, stats %+v, want the trace dropped", tt.name, forwarded, stats)
+		}
+	}
+}
+
+func TestTailSamplingKeepsWholeTrace(t *testing.T) {
+	// 任意一个 span 出错，整条 trace 的 span 都会保留
+	p, next := newTestTailProcessor(t, WithTailPolicies(ErrorPolicy(), LatencyPolicy(time.Second)))
+	id := tailTraceID(1, 0)
+	p.OnEnd(tailSpan(id, 1, time.Millisecond, codes.Unset))
+	p.OnEnd(tailSpan(id, 2, time.Millisecond, codes.Error))
+	p.OnEnd(tailSpan(id, 3, time.Millisecond, codes.Unset))
+	_ = p.ForceFlush(context.Background())
+	if got := next.spans()[id]; got != 3 {
+		t.Fatalf("forwarded %d spans, want all 3", got)
+	}
+	if next.flushed != 1 {
+		t.Fatalf("next processor flushed %d times, want 1", next.flushed)
+	}
+}
+
+func TestTailSamplingEviction(t *testing.T) {
+	p, next := newTestTailProcessor(t, WithTailMaxTraces(2))
+	for i := byte(1); i <= 5; i++ {
+		p.OnEnd(tailSpan(tailTraceID(i, 0), 1, time.Millisecond, codes.Unset))
+	}
+
+	// 超出 maxTraces 时按到达顺序提前决策最早的 trace
+	stats := p.Stats()
+	if stats.TracesEvicted != 3 || stats.PendingTraces != 2 || stats.TracesKept != 3 {
+		t.Fatalf("stats = %+v, want 3 evicted and kept, 2 pending", stats)
+	}
+	forwarded := next.spans()
+	for i := byte(1); i <= 3; i++ {
+		if forwarded[tailTraceID(i, 0)] != 1 {
+			t.Errorf("evicted trace %d was not forwarded", i)
+		}
+	}
+	if forwarded[tailTraceID(4, 0)] != 0 || forwarded[tailTraceID(5, 0)] != 0 {
+		t.Error("pending traces were forwarded before the decision")
+	}
+}
+
+func TestTailSamplingMaxSpansPerTrace(t *testing.T) {
+	p, next := newTestTailProcessor(t, WithTailMaxSpansPerTrace(2))
+	id := tailTraceID(1, 0)
+	for i := byte(1); i <= 5; i++ {
+		p.OnEnd(tailSpan(id, i, time.Millisecond, codes.Unset))
+	}
+	_ = p.ForceFlush(context.Background())
+	if got := next.spans()[id]; got != 2 {
+		t.Fatalf("forwarded %d spans, want 2", got)
+	}
+	if stats := p.Stats(); stats.SpansDropped != 3 {
+		t.Fatalf("spans dropped = %d, want 3", stats.SpansDropped)
+	}
+}
+
+func TestTailSamplingLateSpans(t *testing.T) {
+	p, next := newTestTailProcessor(t, WithTailPolicies(ErrorPolicy()))
+	kept, dropped := tailTraceID(1, 0), tailTraceID(2, 0)
+	p.OnEnd(tailSpan(kept, 1, time.Millisecond, codes.Error))
+	p.OnEnd(tailSpan(dropped, 1, time.Millisecond, codes.Unset))
+	_ = p.ForceFlush(context.Background())
+
+	// 决策之后才结束的 span 沿用之前的决策，出错也不会推翻丢弃的决策
+	p.OnEnd(tailSpan(kept, 2, time.Millisecond, codes.Unset))
+	p.OnEnd(tailSpan(dropped, 2, time.Millisecond, codes.Error))
+
+	forwarded := next.spans()
+	if forwarded[kept] != 2 {
+		t.Errorf("kept trace forwarded %d spans, want 2", forwarded[kept])
+	}
+	if forwarded[dropped] != 0 {
+		t.Errorf("dropped trace forwarded %d spans, want 0", forwarded[dropped])
+	}
+	stats := p.Stats()
+	if stats.LateSpans != 2 || stats.SpansDropped != 2 || stats.PendingTraces != 0 {
+		t.Fatalf("stats = %+v, want 2 late and 2 dropped spans", stats)
+	}
+	if stats.TracesKept != 1 || stats.TracesDropped != 1 {
+		t.Fatalf("late spans changed the trace counts: %+v", stats)
+	}
+}
+
+func TestTailSamplingDecisionWait(t *testing.T) {
+	next := &recordingProcessor{}
+	p := NewTailSamplingProcessor(next, WithTailDecisionWait(20*time.Millisecond))
+	defer p.Shutdown(context.Background())
+
+	id := tailTraceID(1, 0)
+	p.OnEnd(tailSpan(id, 1, time.Millisecond, codes.Unset))
+	deadline := time.Now().Add(2 * time.Second)
+	for next.spans()[id] == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("trace was not decided after the decision wait")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTailSamplingShutdownFlushes(t *testing.T) {
+	next := &recordingProcessor{}
+	p := NewTailSamplingProcessor(next, WithTailDecisionWait(time.Hour))
+	for i := byte(1); i <= 3; i++ {
+		p.OnEnd(tailSpan(tailTraceID(i, 0), 1, time.Millisecond, codes.Unset))
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if got := len(next.spans()); got != 3 {
+		t.Fatalf("shutdown forwarded %d traces, want 3", got)
+	}
+	if !next.shutdown {
+		t.Fatal("next processor was not shut down")
+	}
+	if stats := p.Stats(); stats.PendingTraces != 0 || stats.TracesKept != 3 {
+		t.Fatalf("stats after shutdown = %+v", stats)
+	}
+	// 重复 Shutdown 不会阻塞或 panic
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("second shutdown: %v", err)
+	}
+}
+
+func TestTailSamplingConcurrentSpans(t *testing.T) {
+	p, next := newTestTailProcessor(t)
+	const (
+		workers   = 8
+		perWorker = 50
+		spans     = 4
+	)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				id := tailTraceID(byte(w+1), uint64(i))
+				for s := byte(1); s <= spans; s++ {
+					p.OnEnd(tailSpan(id, s, time.Millisecond, codes.Unset))
+				}
+				if i%10 == 0 {
+					_ = p.ForceFlush(context.Background())
+					_ = p.Stats()
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	_ = p.ForceFlush(context.Background())
+
+	// 决策前后结束的 span 都只转发一次，每条 trace 只计一次保留
+	forwarded := next.spans()
+	if len(forwarded) != workers*perWorker {
+		t.Fatalf("forwarded %d traces, want %d", len(forwarded), workers*perWorker)
+	}
+	for id, n := range forwarded {
+		if n != spans {
+			t.Fatalf("trace %s forwarded %d spans, want %d", id, n, spans)
+		}
+	}
+	if stats := p.Stats(); stats.TracesKept != workers*perWorker || stats.PendingTraces != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}